@@ -0,0 +1,265 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package ERCs
+
+import (
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+)
+
+// DelegateRegistryV1ABI is the input ABI used to generate the binding from.
+const DelegateRegistryV1ABI = "[{\"constant\":true,\"inputs\":[{\"name\":\"delegate\",\"type\":\"address\"},{\"name\":\"vault\",\"type\":\"address\"}],\"name\":\"checkDelegateForAll\",\"outputs\":[{\"name\":\"\",\"type\":\"bool\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"delegate\",\"type\":\"address\"},{\"name\":\"vault\",\"type\":\"address\"},{\"name\":\"contract_\",\"type\":\"address\"}],\"name\":\"checkDelegateForContract\",\"outputs\":[{\"name\":\"\",\"type\":\"bool\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"delegate\",\"type\":\"address\"},{\"name\":\"vault\",\"type\":\"address\"},{\"name\":\"contract_\",\"type\":\"address\"},{\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"checkDelegateForToken\",\"outputs\":[{\"name\":\"\",\"type\":\"bool\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"}]"
+
+// DelegateRegistryV1 is an auto generated Go binding around an Ethereum contract.
+type DelegateRegistryV1 struct {
+	DelegateRegistryV1Caller     // Read-only binding to the contract
+	DelegateRegistryV1Transactor // Write-only binding to the contract
+	DelegateRegistryV1Filterer   // Log filterer for contract events
+}
+
+// DelegateRegistryV1Caller is an auto generated read-only Go binding around an Ethereum contract.
+type DelegateRegistryV1Caller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// DelegateRegistryV1Transactor is an auto generated write-only Go binding around an Ethereum contract.
+type DelegateRegistryV1Transactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// DelegateRegistryV1Filterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type DelegateRegistryV1Filterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// DelegateRegistryV1Session is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type DelegateRegistryV1Session struct {
+	Contract     *DelegateRegistryV1 // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts       // Call options to use throughout this session
+	TransactOpts bind.TransactOpts   // Transaction auth options to use throughout this session
+}
+
+// DelegateRegistryV1CallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type DelegateRegistryV1CallerSession struct {
+	Contract *DelegateRegistryV1Caller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts             // Call options to use throughout this session
+}
+
+// DelegateRegistryV1TransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type DelegateRegistryV1TransactorSession struct {
+	Contract     *DelegateRegistryV1Transactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts             // Transaction auth options to use throughout this session
+}
+
+// DelegateRegistryV1Raw is an auto generated low-level Go binding around an Ethereum contract.
+type DelegateRegistryV1Raw struct {
+	Contract *DelegateRegistryV1 // Generic contract binding to access the raw methods on
+}
+
+// DelegateRegistryV1CallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type DelegateRegistryV1CallerRaw struct {
+	Contract *DelegateRegistryV1Caller // Generic read-only contract binding to access the raw methods on
+}
+
+// DelegateRegistryV1TransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type DelegateRegistryV1TransactorRaw struct {
+	Contract *DelegateRegistryV1Transactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewDelegateRegistryV1 creates a new instance of DelegateRegistryV1, bound to a specific deployed contract.
+func NewDelegateRegistryV1(address common.Address, backend bind.ContractBackend) (*DelegateRegistryV1, error) {
+	contract, err := bindDelegateRegistryV1(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &DelegateRegistryV1{DelegateRegistryV1Caller: DelegateRegistryV1Caller{contract: contract}, DelegateRegistryV1Transactor: DelegateRegistryV1Transactor{contract: contract}, DelegateRegistryV1Filterer: DelegateRegistryV1Filterer{contract: contract}}, nil
+}
+
+// NewDelegateRegistryV1Caller creates a new read-only instance of DelegateRegistryV1, bound to a specific deployed contract.
+func NewDelegateRegistryV1Caller(address common.Address, caller bind.ContractCaller) (*DelegateRegistryV1Caller, error) {
+	contract, err := bindDelegateRegistryV1(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &DelegateRegistryV1Caller{contract: contract}, nil
+}
+
+// NewDelegateRegistryV1Transactor creates a new write-only instance of DelegateRegistryV1, bound to a specific deployed contract.
+func NewDelegateRegistryV1Transactor(address common.Address, transactor bind.ContractTransactor) (*DelegateRegistryV1Transactor, error) {
+	contract, err := bindDelegateRegistryV1(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &DelegateRegistryV1Transactor{contract: contract}, nil
+}
+
+// NewDelegateRegistryV1Filterer creates a new log filterer instance of DelegateRegistryV1, bound to a specific deployed contract.
+func NewDelegateRegistryV1Filterer(address common.Address, filterer bind.ContractFilterer) (*DelegateRegistryV1Filterer, error) {
+	contract, err := bindDelegateRegistryV1(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &DelegateRegistryV1Filterer{contract: contract}, nil
+}
+
+// bindDelegateRegistryV1 binds a generic wrapper to an already deployed contract.
+func bindDelegateRegistryV1(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(DelegateRegistryV1ABI))
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_DelegateRegistryV1 *DelegateRegistryV1Raw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _DelegateRegistryV1.Contract.DelegateRegistryV1Caller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_DelegateRegistryV1 *DelegateRegistryV1Raw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _DelegateRegistryV1.Contract.DelegateRegistryV1Transactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_DelegateRegistryV1 *DelegateRegistryV1Raw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _DelegateRegistryV1.Contract.DelegateRegistryV1Transactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_DelegateRegistryV1 *DelegateRegistryV1CallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _DelegateRegistryV1.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_DelegateRegistryV1 *DelegateRegistryV1TransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _DelegateRegistryV1.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_DelegateRegistryV1 *DelegateRegistryV1TransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _DelegateRegistryV1.Contract.contract.Transact(opts, method, params...)
+}
+
+// CheckDelegateForAll is a free data retrieval call binding the contract method 0x9c395bc2.
+//
+// Solidity: function checkDelegateForAll(address delegate, address vault) view returns(bool)
+func (_DelegateRegistryV1 *DelegateRegistryV1Caller) CheckDelegateForAll(opts *bind.CallOpts, delegate common.Address, vault common.Address) (bool, error) {
+	var out []interface{}
+	err := _DelegateRegistryV1.contract.Call(opts, &out, "checkDelegateForAll", delegate, vault)
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// CheckDelegateForAll is a free data retrieval call binding the contract method 0x9c395bc2.
+//
+// Solidity: function checkDelegateForAll(address delegate, address vault) view returns(bool)
+func (_DelegateRegistryV1 *DelegateRegistryV1Session) CheckDelegateForAll(delegate common.Address, vault common.Address) (bool, error) {
+	return _DelegateRegistryV1.Contract.CheckDelegateForAll(&_DelegateRegistryV1.CallOpts, delegate, vault)
+}
+
+// CheckDelegateForAll is a free data retrieval call binding the contract method 0x9c395bc2.
+//
+// Solidity: function checkDelegateForAll(address delegate, address vault) view returns(bool)
+func (_DelegateRegistryV1 *DelegateRegistryV1CallerSession) CheckDelegateForAll(delegate common.Address, vault common.Address) (bool, error) {
+	return _DelegateRegistryV1.Contract.CheckDelegateForAll(&_DelegateRegistryV1.CallOpts, delegate, vault)
+}
+
+// CheckDelegateForContract is a free data retrieval call binding the contract method 0x90c9a2d0.
+//
+// Solidity: function checkDelegateForContract(address delegate, address vault, address contract_) view returns(bool)
+func (_DelegateRegistryV1 *DelegateRegistryV1Caller) CheckDelegateForContract(opts *bind.CallOpts, delegate common.Address, vault common.Address, contract_ common.Address) (bool, error) {
+	var out []interface{}
+	err := _DelegateRegistryV1.contract.Call(opts, &out, "checkDelegateForContract", delegate, vault, contract_)
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// CheckDelegateForContract is a free data retrieval call binding the contract method 0x90c9a2d0.
+//
+// Solidity: function checkDelegateForContract(address delegate, address vault, address contract_) view returns(bool)
+func (_DelegateRegistryV1 *DelegateRegistryV1Session) CheckDelegateForContract(delegate common.Address, vault common.Address, contract_ common.Address) (bool, error) {
+	return _DelegateRegistryV1.Contract.CheckDelegateForContract(&_DelegateRegistryV1.CallOpts, delegate, vault, contract_)
+}
+
+// CheckDelegateForContract is a free data retrieval call binding the contract method 0x90c9a2d0.
+//
+// Solidity: function checkDelegateForContract(address delegate, address vault, address contract_) view returns(bool)
+func (_DelegateRegistryV1 *DelegateRegistryV1CallerSession) CheckDelegateForContract(delegate common.Address, vault common.Address, contract_ common.Address) (bool, error) {
+	return _DelegateRegistryV1.Contract.CheckDelegateForContract(&_DelegateRegistryV1.CallOpts, delegate, vault, contract_)
+}
+
+// CheckDelegateForToken is a free data retrieval call binding the contract method 0xaba69cf8.
+//
+// Solidity: function checkDelegateForToken(address delegate, address vault, address contract_, uint256 tokenId) view returns(bool)
+func (_DelegateRegistryV1 *DelegateRegistryV1Caller) CheckDelegateForToken(opts *bind.CallOpts, delegate common.Address, vault common.Address, contract_ common.Address, tokenId *big.Int) (bool, error) {
+	var out []interface{}
+	err := _DelegateRegistryV1.contract.Call(opts, &out, "checkDelegateForToken", delegate, vault, contract_, tokenId)
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// CheckDelegateForToken is a free data retrieval call binding the contract method 0xaba69cf8.
+//
+// Solidity: function checkDelegateForToken(address delegate, address vault, address contract_, uint256 tokenId) view returns(bool)
+func (_DelegateRegistryV1 *DelegateRegistryV1Session) CheckDelegateForToken(delegate common.Address, vault common.Address, contract_ common.Address, tokenId *big.Int) (bool, error) {
+	return _DelegateRegistryV1.Contract.CheckDelegateForToken(&_DelegateRegistryV1.CallOpts, delegate, vault, contract_, tokenId)
+}
+
+// CheckDelegateForToken is a free data retrieval call binding the contract method 0xaba69cf8.
+//
+// Solidity: function checkDelegateForToken(address delegate, address vault, address contract_, uint256 tokenId) view returns(bool)
+func (_DelegateRegistryV1 *DelegateRegistryV1CallerSession) CheckDelegateForToken(delegate common.Address, vault common.Address, contract_ common.Address, tokenId *big.Int) (bool, error) {
+	return _DelegateRegistryV1.Contract.CheckDelegateForToken(&_DelegateRegistryV1.CallOpts, delegate, vault, contract_, tokenId)
+}
@@ -0,0 +1,265 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package ERCs
+
+import (
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+)
+
+// DelegateRegistryV2ABI is the input ABI used to generate the binding from.
+const DelegateRegistryV2ABI = "[{\"constant\":true,\"inputs\":[{\"name\":\"to\",\"type\":\"address\"},{\"name\":\"from\",\"type\":\"address\"},{\"name\":\"rights\",\"type\":\"bytes32\"}],\"name\":\"checkDelegateForAll\",\"outputs\":[{\"name\":\"\",\"type\":\"bool\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"to\",\"type\":\"address\"},{\"name\":\"from\",\"type\":\"address\"},{\"name\":\"contract_\",\"type\":\"address\"},{\"name\":\"rights\",\"type\":\"bytes32\"}],\"name\":\"checkDelegateForContract\",\"outputs\":[{\"name\":\"\",\"type\":\"bool\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"to\",\"type\":\"address\"},{\"name\":\"from\",\"type\":\"address\"},{\"name\":\"contract_\",\"type\":\"address\"},{\"name\":\"tokenId\",\"type\":\"uint256\"},{\"name\":\"rights\",\"type\":\"bytes32\"}],\"name\":\"checkDelegateForERC721\",\"outputs\":[{\"name\":\"\",\"type\":\"bool\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"}]"
+
+// DelegateRegistryV2 is an auto generated Go binding around an Ethereum contract.
+type DelegateRegistryV2 struct {
+	DelegateRegistryV2Caller     // Read-only binding to the contract
+	DelegateRegistryV2Transactor // Write-only binding to the contract
+	DelegateRegistryV2Filterer   // Log filterer for contract events
+}
+
+// DelegateRegistryV2Caller is an auto generated read-only Go binding around an Ethereum contract.
+type DelegateRegistryV2Caller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// DelegateRegistryV2Transactor is an auto generated write-only Go binding around an Ethereum contract.
+type DelegateRegistryV2Transactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// DelegateRegistryV2Filterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type DelegateRegistryV2Filterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// DelegateRegistryV2Session is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type DelegateRegistryV2Session struct {
+	Contract     *DelegateRegistryV2 // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts       // Call options to use throughout this session
+	TransactOpts bind.TransactOpts   // Transaction auth options to use throughout this session
+}
+
+// DelegateRegistryV2CallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type DelegateRegistryV2CallerSession struct {
+	Contract *DelegateRegistryV2Caller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts             // Call options to use throughout this session
+}
+
+// DelegateRegistryV2TransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type DelegateRegistryV2TransactorSession struct {
+	Contract     *DelegateRegistryV2Transactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts             // Transaction auth options to use throughout this session
+}
+
+// DelegateRegistryV2Raw is an auto generated low-level Go binding around an Ethereum contract.
+type DelegateRegistryV2Raw struct {
+	Contract *DelegateRegistryV2 // Generic contract binding to access the raw methods on
+}
+
+// DelegateRegistryV2CallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type DelegateRegistryV2CallerRaw struct {
+	Contract *DelegateRegistryV2Caller // Generic read-only contract binding to access the raw methods on
+}
+
+// DelegateRegistryV2TransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type DelegateRegistryV2TransactorRaw struct {
+	Contract *DelegateRegistryV2Transactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewDelegateRegistryV2 creates a new instance of DelegateRegistryV2, bound to a specific deployed contract.
+func NewDelegateRegistryV2(address common.Address, backend bind.ContractBackend) (*DelegateRegistryV2, error) {
+	contract, err := bindDelegateRegistryV2(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &DelegateRegistryV2{DelegateRegistryV2Caller: DelegateRegistryV2Caller{contract: contract}, DelegateRegistryV2Transactor: DelegateRegistryV2Transactor{contract: contract}, DelegateRegistryV2Filterer: DelegateRegistryV2Filterer{contract: contract}}, nil
+}
+
+// NewDelegateRegistryV2Caller creates a new read-only instance of DelegateRegistryV2, bound to a specific deployed contract.
+func NewDelegateRegistryV2Caller(address common.Address, caller bind.ContractCaller) (*DelegateRegistryV2Caller, error) {
+	contract, err := bindDelegateRegistryV2(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &DelegateRegistryV2Caller{contract: contract}, nil
+}
+
+// NewDelegateRegistryV2Transactor creates a new write-only instance of DelegateRegistryV2, bound to a specific deployed contract.
+func NewDelegateRegistryV2Transactor(address common.Address, transactor bind.ContractTransactor) (*DelegateRegistryV2Transactor, error) {
+	contract, err := bindDelegateRegistryV2(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &DelegateRegistryV2Transactor{contract: contract}, nil
+}
+
+// NewDelegateRegistryV2Filterer creates a new log filterer instance of DelegateRegistryV2, bound to a specific deployed contract.
+func NewDelegateRegistryV2Filterer(address common.Address, filterer bind.ContractFilterer) (*DelegateRegistryV2Filterer, error) {
+	contract, err := bindDelegateRegistryV2(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &DelegateRegistryV2Filterer{contract: contract}, nil
+}
+
+// bindDelegateRegistryV2 binds a generic wrapper to an already deployed contract.
+func bindDelegateRegistryV2(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(DelegateRegistryV2ABI))
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_DelegateRegistryV2 *DelegateRegistryV2Raw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _DelegateRegistryV2.Contract.DelegateRegistryV2Caller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_DelegateRegistryV2 *DelegateRegistryV2Raw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _DelegateRegistryV2.Contract.DelegateRegistryV2Transactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_DelegateRegistryV2 *DelegateRegistryV2Raw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _DelegateRegistryV2.Contract.DelegateRegistryV2Transactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_DelegateRegistryV2 *DelegateRegistryV2CallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _DelegateRegistryV2.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_DelegateRegistryV2 *DelegateRegistryV2TransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _DelegateRegistryV2.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_DelegateRegistryV2 *DelegateRegistryV2TransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _DelegateRegistryV2.Contract.contract.Transact(opts, method, params...)
+}
+
+// CheckDelegateForAll is a free data retrieval call binding the contract method 0xe839bd53.
+//
+// Solidity: function checkDelegateForAll(address to, address from, bytes32 rights) view returns(bool)
+func (_DelegateRegistryV2 *DelegateRegistryV2Caller) CheckDelegateForAll(opts *bind.CallOpts, to common.Address, from common.Address, rights [32]byte) (bool, error) {
+	var out []interface{}
+	err := _DelegateRegistryV2.contract.Call(opts, &out, "checkDelegateForAll", to, from, rights)
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// CheckDelegateForAll is a free data retrieval call binding the contract method 0xe839bd53.
+//
+// Solidity: function checkDelegateForAll(address to, address from, bytes32 rights) view returns(bool)
+func (_DelegateRegistryV2 *DelegateRegistryV2Session) CheckDelegateForAll(to common.Address, from common.Address, rights [32]byte) (bool, error) {
+	return _DelegateRegistryV2.Contract.CheckDelegateForAll(&_DelegateRegistryV2.CallOpts, to, from, rights)
+}
+
+// CheckDelegateForAll is a free data retrieval call binding the contract method 0xe839bd53.
+//
+// Solidity: function checkDelegateForAll(address to, address from, bytes32 rights) view returns(bool)
+func (_DelegateRegistryV2 *DelegateRegistryV2CallerSession) CheckDelegateForAll(to common.Address, from common.Address, rights [32]byte) (bool, error) {
+	return _DelegateRegistryV2.Contract.CheckDelegateForAll(&_DelegateRegistryV2.CallOpts, to, from, rights)
+}
+
+// CheckDelegateForContract is a free data retrieval call binding the contract method 0x8988eea9.
+//
+// Solidity: function checkDelegateForContract(address to, address from, address contract_, bytes32 rights) view returns(bool)
+func (_DelegateRegistryV2 *DelegateRegistryV2Caller) CheckDelegateForContract(opts *bind.CallOpts, to common.Address, from common.Address, contract_ common.Address, rights [32]byte) (bool, error) {
+	var out []interface{}
+	err := _DelegateRegistryV2.contract.Call(opts, &out, "checkDelegateForContract", to, from, contract_, rights)
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// CheckDelegateForContract is a free data retrieval call binding the contract method 0x8988eea9.
+//
+// Solidity: function checkDelegateForContract(address to, address from, address contract_, bytes32 rights) view returns(bool)
+func (_DelegateRegistryV2 *DelegateRegistryV2Session) CheckDelegateForContract(to common.Address, from common.Address, contract_ common.Address, rights [32]byte) (bool, error) {
+	return _DelegateRegistryV2.Contract.CheckDelegateForContract(&_DelegateRegistryV2.CallOpts, to, from, contract_, rights)
+}
+
+// CheckDelegateForContract is a free data retrieval call binding the contract method 0x8988eea9.
+//
+// Solidity: function checkDelegateForContract(address to, address from, address contract_, bytes32 rights) view returns(bool)
+func (_DelegateRegistryV2 *DelegateRegistryV2CallerSession) CheckDelegateForContract(to common.Address, from common.Address, contract_ common.Address, rights [32]byte) (bool, error) {
+	return _DelegateRegistryV2.Contract.CheckDelegateForContract(&_DelegateRegistryV2.CallOpts, to, from, contract_, rights)
+}
+
+// CheckDelegateForERC721 is a free data retrieval call binding the contract method 0xb9f36874.
+//
+// Solidity: function checkDelegateForERC721(address to, address from, address contract_, uint256 tokenId, bytes32 rights) view returns(bool)
+func (_DelegateRegistryV2 *DelegateRegistryV2Caller) CheckDelegateForERC721(opts *bind.CallOpts, to common.Address, from common.Address, contract_ common.Address, tokenId *big.Int, rights [32]byte) (bool, error) {
+	var out []interface{}
+	err := _DelegateRegistryV2.contract.Call(opts, &out, "checkDelegateForERC721", to, from, contract_, tokenId, rights)
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// CheckDelegateForERC721 is a free data retrieval call binding the contract method 0xb9f36874.
+//
+// Solidity: function checkDelegateForERC721(address to, address from, address contract_, uint256 tokenId, bytes32 rights) view returns(bool)
+func (_DelegateRegistryV2 *DelegateRegistryV2Session) CheckDelegateForERC721(to common.Address, from common.Address, contract_ common.Address, tokenId *big.Int, rights [32]byte) (bool, error) {
+	return _DelegateRegistryV2.Contract.CheckDelegateForERC721(&_DelegateRegistryV2.CallOpts, to, from, contract_, tokenId, rights)
+}
+
+// CheckDelegateForERC721 is a free data retrieval call binding the contract method 0xb9f36874.
+//
+// Solidity: function checkDelegateForERC721(address to, address from, address contract_, uint256 tokenId, bytes32 rights) view returns(bool)
+func (_DelegateRegistryV2 *DelegateRegistryV2CallerSession) CheckDelegateForERC721(to common.Address, from common.Address, contract_ common.Address, tokenId *big.Int, rights [32]byte) (bool, error) {
+	return _DelegateRegistryV2.Contract.CheckDelegateForERC721(&_DelegateRegistryV2.CallOpts, to, from, contract_, tokenId, rights)
+}
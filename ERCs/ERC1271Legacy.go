@@ -0,0 +1,203 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package ERCs
+
+import (
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+)
+
+// ERC1271LegacyABI is the input ABI used to generate the binding from.
+const ERC1271LegacyABI = "[{\"constant\":true,\"inputs\":[{\"name\":\"_data\",\"type\":\"bytes\"},{\"name\":\"_signature\",\"type\":\"bytes\"}],\"name\":\"isValidSignature\",\"outputs\":[{\"name\":\"magicValue\",\"type\":\"bytes4\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"}]"
+
+// ERC1271Legacy is an auto generated Go binding around an Ethereum contract.
+type ERC1271Legacy struct {
+	ERC1271LegacyCaller     // Read-only binding to the contract
+	ERC1271LegacyTransactor // Write-only binding to the contract
+	ERC1271LegacyFilterer   // Log filterer for contract events
+}
+
+// ERC1271LegacyCaller is an auto generated read-only Go binding around an Ethereum contract.
+type ERC1271LegacyCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ERC1271LegacyTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type ERC1271LegacyTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ERC1271LegacyFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type ERC1271LegacyFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ERC1271LegacySession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type ERC1271LegacySession struct {
+	Contract     *ERC1271Legacy    // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// ERC1271LegacyCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type ERC1271LegacyCallerSession struct {
+	Contract *ERC1271LegacyCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts        // Call options to use throughout this session
+}
+
+// ERC1271LegacyTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type ERC1271LegacyTransactorSession struct {
+	Contract     *ERC1271LegacyTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts        // Transaction auth options to use throughout this session
+}
+
+// ERC1271LegacyRaw is an auto generated low-level Go binding around an Ethereum contract.
+type ERC1271LegacyRaw struct {
+	Contract *ERC1271Legacy // Generic contract binding to access the raw methods on
+}
+
+// ERC1271LegacyCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type ERC1271LegacyCallerRaw struct {
+	Contract *ERC1271LegacyCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// ERC1271LegacyTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type ERC1271LegacyTransactorRaw struct {
+	Contract *ERC1271LegacyTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewERC1271Legacy creates a new instance of ERC1271Legacy, bound to a specific deployed contract.
+func NewERC1271Legacy(address common.Address, backend bind.ContractBackend) (*ERC1271Legacy, error) {
+	contract, err := bindERC1271Legacy(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &ERC1271Legacy{ERC1271LegacyCaller: ERC1271LegacyCaller{contract: contract}, ERC1271LegacyTransactor: ERC1271LegacyTransactor{contract: contract}, ERC1271LegacyFilterer: ERC1271LegacyFilterer{contract: contract}}, nil
+}
+
+// NewERC1271LegacyCaller creates a new read-only instance of ERC1271Legacy, bound to a specific deployed contract.
+func NewERC1271LegacyCaller(address common.Address, caller bind.ContractCaller) (*ERC1271LegacyCaller, error) {
+	contract, err := bindERC1271Legacy(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ERC1271LegacyCaller{contract: contract}, nil
+}
+
+// NewERC1271LegacyTransactor creates a new write-only instance of ERC1271Legacy, bound to a specific deployed contract.
+func NewERC1271LegacyTransactor(address common.Address, transactor bind.ContractTransactor) (*ERC1271LegacyTransactor, error) {
+	contract, err := bindERC1271Legacy(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ERC1271LegacyTransactor{contract: contract}, nil
+}
+
+// NewERC1271LegacyFilterer creates a new log filterer instance of ERC1271Legacy, bound to a specific deployed contract.
+func NewERC1271LegacyFilterer(address common.Address, filterer bind.ContractFilterer) (*ERC1271LegacyFilterer, error) {
+	contract, err := bindERC1271Legacy(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &ERC1271LegacyFilterer{contract: contract}, nil
+}
+
+// bindERC1271Legacy binds a generic wrapper to an already deployed contract.
+func bindERC1271Legacy(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(ERC1271LegacyABI))
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_ERC1271Legacy *ERC1271LegacyRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _ERC1271Legacy.Contract.ERC1271LegacyCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_ERC1271Legacy *ERC1271LegacyRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _ERC1271Legacy.Contract.ERC1271LegacyTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_ERC1271Legacy *ERC1271LegacyRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _ERC1271Legacy.Contract.ERC1271LegacyTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_ERC1271Legacy *ERC1271LegacyCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _ERC1271Legacy.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_ERC1271Legacy *ERC1271LegacyTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _ERC1271Legacy.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_ERC1271Legacy *ERC1271LegacyTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _ERC1271Legacy.Contract.contract.Transact(opts, method, params...)
+}
+
+// IsValidSignature is a free data retrieval call binding the contract method 0x20c13b0b.
+//
+// Solidity: function isValidSignature(bytes _data, bytes _signature) view returns(bytes4 magicValue)
+func (_ERC1271Legacy *ERC1271LegacyCaller) IsValidSignature(opts *bind.CallOpts, _data []byte, _signature []byte) ([4]byte, error) {
+	var out []interface{}
+	err := _ERC1271Legacy.contract.Call(opts, &out, "isValidSignature", _data, _signature)
+
+	if err != nil {
+		return *new([4]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([4]byte)).(*[4]byte)
+
+	return out0, err
+
+}
+
+// IsValidSignature is a free data retrieval call binding the contract method 0x20c13b0b.
+//
+// Solidity: function isValidSignature(bytes _data, bytes _signature) view returns(bytes4 magicValue)
+func (_ERC1271Legacy *ERC1271LegacySession) IsValidSignature(_data []byte, _signature []byte) ([4]byte, error) {
+	return _ERC1271Legacy.Contract.IsValidSignature(&_ERC1271Legacy.CallOpts, _data, _signature)
+}
+
+// IsValidSignature is a free data retrieval call binding the contract method 0x20c13b0b.
+//
+// Solidity: function isValidSignature(bytes _data, bytes _signature) view returns(bytes4 magicValue)
+func (_ERC1271Legacy *ERC1271LegacyCallerSession) IsValidSignature(_data []byte, _signature []byte) ([4]byte, error) {
+	return _ERC1271Legacy.Contract.IsValidSignature(&_ERC1271Legacy.CallOpts, _data, _signature)
+}
@@ -0,0 +1,296 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package ERCs
+
+import (
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+)
+
+// GnosisSafeABI is the input ABI used to generate the binding from.
+const GnosisSafeABI = "[{\"constant\":true,\"inputs\":[],\"name\":\"getOwners\",\"outputs\":[{\"name\":\"\",\"type\":\"address[]\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[],\"name\":\"getThreshold\",\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[],\"name\":\"domainSeparator\",\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"owner\",\"type\":\"address\"},{\"name\":\"hash\",\"type\":\"bytes32\"}],\"name\":\"approvedHashes\",\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"}]"
+
+// GnosisSafe is an auto generated Go binding around an Ethereum contract.
+type GnosisSafe struct {
+	GnosisSafeCaller     // Read-only binding to the contract
+	GnosisSafeTransactor // Write-only binding to the contract
+	GnosisSafeFilterer   // Log filterer for contract events
+}
+
+// GnosisSafeCaller is an auto generated read-only Go binding around an Ethereum contract.
+type GnosisSafeCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// GnosisSafeTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type GnosisSafeTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// GnosisSafeFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type GnosisSafeFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// GnosisSafeSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type GnosisSafeSession struct {
+	Contract     *GnosisSafe       // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// GnosisSafeCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type GnosisSafeCallerSession struct {
+	Contract *GnosisSafeCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts     // Call options to use throughout this session
+}
+
+// GnosisSafeTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type GnosisSafeTransactorSession struct {
+	Contract     *GnosisSafeTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts     // Transaction auth options to use throughout this session
+}
+
+// GnosisSafeRaw is an auto generated low-level Go binding around an Ethereum contract.
+type GnosisSafeRaw struct {
+	Contract *GnosisSafe // Generic contract binding to access the raw methods on
+}
+
+// GnosisSafeCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type GnosisSafeCallerRaw struct {
+	Contract *GnosisSafeCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// GnosisSafeTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type GnosisSafeTransactorRaw struct {
+	Contract *GnosisSafeTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewGnosisSafe creates a new instance of GnosisSafe, bound to a specific deployed contract.
+func NewGnosisSafe(address common.Address, backend bind.ContractBackend) (*GnosisSafe, error) {
+	contract, err := bindGnosisSafe(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &GnosisSafe{GnosisSafeCaller: GnosisSafeCaller{contract: contract}, GnosisSafeTransactor: GnosisSafeTransactor{contract: contract}, GnosisSafeFilterer: GnosisSafeFilterer{contract: contract}}, nil
+}
+
+// NewGnosisSafeCaller creates a new read-only instance of GnosisSafe, bound to a specific deployed contract.
+func NewGnosisSafeCaller(address common.Address, caller bind.ContractCaller) (*GnosisSafeCaller, error) {
+	contract, err := bindGnosisSafe(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &GnosisSafeCaller{contract: contract}, nil
+}
+
+// NewGnosisSafeTransactor creates a new write-only instance of GnosisSafe, bound to a specific deployed contract.
+func NewGnosisSafeTransactor(address common.Address, transactor bind.ContractTransactor) (*GnosisSafeTransactor, error) {
+	contract, err := bindGnosisSafe(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &GnosisSafeTransactor{contract: contract}, nil
+}
+
+// NewGnosisSafeFilterer creates a new log filterer instance of GnosisSafe, bound to a specific deployed contract.
+func NewGnosisSafeFilterer(address common.Address, filterer bind.ContractFilterer) (*GnosisSafeFilterer, error) {
+	contract, err := bindGnosisSafe(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &GnosisSafeFilterer{contract: contract}, nil
+}
+
+// bindGnosisSafe binds a generic wrapper to an already deployed contract.
+func bindGnosisSafe(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(GnosisSafeABI))
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_GnosisSafe *GnosisSafeRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _GnosisSafe.Contract.GnosisSafeCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_GnosisSafe *GnosisSafeRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _GnosisSafe.Contract.GnosisSafeTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_GnosisSafe *GnosisSafeRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _GnosisSafe.Contract.GnosisSafeTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_GnosisSafe *GnosisSafeCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _GnosisSafe.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_GnosisSafe *GnosisSafeTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _GnosisSafe.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_GnosisSafe *GnosisSafeTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _GnosisSafe.Contract.contract.Transact(opts, method, params...)
+}
+
+// ApprovedHashes is a free data retrieval call binding the contract method 0x7d832974.
+//
+// Solidity: function approvedHashes(address owner, bytes32 hash) view returns(uint256)
+func (_GnosisSafe *GnosisSafeCaller) ApprovedHashes(opts *bind.CallOpts, owner common.Address, hash [32]byte) (*big.Int, error) {
+	var out []interface{}
+	err := _GnosisSafe.contract.Call(opts, &out, "approvedHashes", owner, hash)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// ApprovedHashes is a free data retrieval call binding the contract method 0x7d832974.
+//
+// Solidity: function approvedHashes(address owner, bytes32 hash) view returns(uint256)
+func (_GnosisSafe *GnosisSafeSession) ApprovedHashes(owner common.Address, hash [32]byte) (*big.Int, error) {
+	return _GnosisSafe.Contract.ApprovedHashes(&_GnosisSafe.CallOpts, owner, hash)
+}
+
+// ApprovedHashes is a free data retrieval call binding the contract method 0x7d832974.
+//
+// Solidity: function approvedHashes(address owner, bytes32 hash) view returns(uint256)
+func (_GnosisSafe *GnosisSafeCallerSession) ApprovedHashes(owner common.Address, hash [32]byte) (*big.Int, error) {
+	return _GnosisSafe.Contract.ApprovedHashes(&_GnosisSafe.CallOpts, owner, hash)
+}
+
+// DomainSeparator is a free data retrieval call binding the contract method 0xf698da25.
+//
+// Solidity: function domainSeparator() view returns(bytes32)
+func (_GnosisSafe *GnosisSafeCaller) DomainSeparator(opts *bind.CallOpts) ([32]byte, error) {
+	var out []interface{}
+	err := _GnosisSafe.contract.Call(opts, &out, "domainSeparator")
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// DomainSeparator is a free data retrieval call binding the contract method 0xf698da25.
+//
+// Solidity: function domainSeparator() view returns(bytes32)
+func (_GnosisSafe *GnosisSafeSession) DomainSeparator() ([32]byte, error) {
+	return _GnosisSafe.Contract.DomainSeparator(&_GnosisSafe.CallOpts)
+}
+
+// DomainSeparator is a free data retrieval call binding the contract method 0xf698da25.
+//
+// Solidity: function domainSeparator() view returns(bytes32)
+func (_GnosisSafe *GnosisSafeCallerSession) DomainSeparator() ([32]byte, error) {
+	return _GnosisSafe.Contract.DomainSeparator(&_GnosisSafe.CallOpts)
+}
+
+// GetOwners is a free data retrieval call binding the contract method 0xa0e67e2b.
+//
+// Solidity: function getOwners() view returns(address[])
+func (_GnosisSafe *GnosisSafeCaller) GetOwners(opts *bind.CallOpts) ([]common.Address, error) {
+	var out []interface{}
+	err := _GnosisSafe.contract.Call(opts, &out, "getOwners")
+
+	if err != nil {
+		return *new([]common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([]common.Address)).(*[]common.Address)
+
+	return out0, err
+
+}
+
+// GetOwners is a free data retrieval call binding the contract method 0xa0e67e2b.
+//
+// Solidity: function getOwners() view returns(address[])
+func (_GnosisSafe *GnosisSafeSession) GetOwners() ([]common.Address, error) {
+	return _GnosisSafe.Contract.GetOwners(&_GnosisSafe.CallOpts)
+}
+
+// GetOwners is a free data retrieval call binding the contract method 0xa0e67e2b.
+//
+// Solidity: function getOwners() view returns(address[])
+func (_GnosisSafe *GnosisSafeCallerSession) GetOwners() ([]common.Address, error) {
+	return _GnosisSafe.Contract.GetOwners(&_GnosisSafe.CallOpts)
+}
+
+// GetThreshold is a free data retrieval call binding the contract method 0xe75235b8.
+//
+// Solidity: function getThreshold() view returns(uint256)
+func (_GnosisSafe *GnosisSafeCaller) GetThreshold(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _GnosisSafe.contract.Call(opts, &out, "getThreshold")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// GetThreshold is a free data retrieval call binding the contract method 0xe75235b8.
+//
+// Solidity: function getThreshold() view returns(uint256)
+func (_GnosisSafe *GnosisSafeSession) GetThreshold() (*big.Int, error) {
+	return _GnosisSafe.Contract.GetThreshold(&_GnosisSafe.CallOpts)
+}
+
+// GetThreshold is a free data retrieval call binding the contract method 0xe75235b8.
+//
+// Solidity: function getThreshold() view returns(uint256)
+func (_GnosisSafe *GnosisSafeCallerSession) GetThreshold() (*big.Int, error) {
+	return _GnosisSafe.Contract.GetThreshold(&_GnosisSafe.CallOpts)
+}
@@ -0,0 +1,62 @@
+package dappauth
+
+import (
+	"fmt"
+	"math/big"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var base58Indexes = func() [256]int {
+	var indexes [256]int
+	for i := range indexes {
+		indexes[i] = -1
+	}
+	for i, c := range base58Alphabet {
+		indexes[c] = i
+	}
+	return indexes
+}()
+
+// base58Decode decodes a string of the Bitcoin base58 alphabet, keeping leading zero bytes.
+func base58Decode(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	zeros := 0
+	for i := 0; i < len(s); i++ {
+		index := base58Indexes[s[i]]
+		if index < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", s[i])
+		}
+		if index == 0 && n.Sign() == 0 {
+			zeros++
+			continue
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(index)))
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+// base58Encode encodes bytes with the Bitcoin base58 alphabet, keeping leading zero bytes.
+func base58Encode(b []byte) string {
+	n := new(big.Int).SetBytes(b)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+
+	var encoded []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		encoded = append(encoded, base58Alphabet[mod.Int64()])
+	}
+	for _, v := range b {
+		if v != 0 {
+			break
+		}
+		encoded = append(encoded, base58Alphabet[0])
+	}
+	for i, j := 0, len(encoded)-1; i < j; i, j = i+1, j-1 {
+		encoded[i], encoded[j] = encoded[j], encoded[i]
+	}
+	return string(encoded)
+}
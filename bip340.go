@@ -0,0 +1,77 @@
+package dappauth
+
+import (
+	"math/big"
+
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// verifySchnorr verifies a BIP-340 schnorr signature of the 32 byte message by the x-only public key.
+func verifySchnorr(publicKey, message, sig []byte) bool {
+	if len(publicKey) != 32 || len(message) != 32 || len(sig) != 64 {
+		return false
+	}
+	curve := ethCrypto.S256()
+	params := curve.Params()
+
+	px, py := liftX(new(big.Int).SetBytes(publicKey))
+	if px == nil {
+		return false
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	if r.Cmp(params.P) >= 0 || s.Cmp(params.N) >= 0 {
+		return false
+	}
+
+	e := new(big.Int).SetBytes(taggedHash("BIP0340/challenge", sig[:32], publicKey, message))
+	e.Mod(e, params.N)
+
+	// R = s*G - e*P
+	sx, sy := curve.ScalarBaseMult(s.Bytes())
+	negE := new(big.Int).Sub(params.N, e)
+	ex, ey := curve.ScalarMult(px, py, negE.Bytes())
+	rx, ry := addPoints(sx, sy, ex, ey)
+	if rx == nil || ry.Bit(0) != 0 {
+		return false
+	}
+	return rx.Cmp(r) == 0
+}
+
+// liftX returns the point with the x coordinate and an even y coordinate, or nil if there is none.
+func liftX(x *big.Int) (*big.Int, *big.Int) {
+	p := ethCrypto.S256().Params().P
+	if x.Cmp(p) >= 0 {
+		return nil, nil
+	}
+
+	// y = sqrt(x^3 + 7), which is c^((p+1)/4) as p = 3 mod 4
+	c := new(big.Int).Exp(x, big.NewInt(3), p)
+	c.Add(c, big.NewInt(7))
+	c.Mod(c, p)
+	y := new(big.Int).Exp(c, new(big.Int).Rsh(new(big.Int).Add(p, big.NewInt(1)), 2), p)
+	if new(big.Int).Exp(y, big.NewInt(2), p).Cmp(c) != 0 {
+		return nil, nil
+	}
+	if y.Bit(0) != 0 {
+		y.Sub(p, y)
+	}
+	return x, y
+}
+
+// addPoints adds two points (nil = infinity), handling the doubling and infinity cases the curve's Add doesn't.
+func addPoints(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	curve := ethCrypto.S256()
+	switch {
+	case x1 == nil:
+		return x2, y2
+	case x2 == nil:
+		return x1, y1
+	case x1.Cmp(x2) == 0 && y1.Cmp(y2) == 0:
+		return curve.Double(x1, y1)
+	case x1.Cmp(x2) == 0:
+		return nil, nil
+	default:
+		return curve.Add(x1, y1, x2, y2)
+	}
+}
@@ -0,0 +1,305 @@
+package dappauth
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+const bitcoinMessagePrefix = "\x18Bitcoin Signed Message:\n"
+
+const (
+	sighashDefault = 0x00
+	sighashAll     = 0x01
+)
+
+// BitcoinVerifier verifies Bitcoin message signatures: BIP-137 compact signatures of P2PKH, P2SH-P2WPKH and P2WPKH addresses,
+// and BIP-322 simple signatures of P2WPKH and P2TR addresses.
+type BitcoinVerifier struct{}
+
+// NewBitcoinVerifier creates a new BitcoinVerifier .
+func NewBitcoinVerifier() *BitcoinVerifier {
+	return &BitcoinVerifier{}
+}
+
+// IsAuthorizedSigner checks if the base64 signature of the challenge was signed by the Bitcoin address, like Authenticator.IsAuthorizedSigner .
+func (v *BitcoinVerifier) IsAuthorizedSigner(challenge, signature, address string) (bool, error) {
+	result, err := v.Verify(challenge, signature, address)
+	if err != nil {
+		return false, err
+	}
+	return result.Authorized, nil
+}
+
+// Verify checks if the base64 signature of the challenge was signed by the Bitcoin address.
+// Bitcoin wallets sign the text of the challenge, so unlike eth_sign challenges, hex challenges are not decoded.
+// 65 byte signatures with a BIP-137 header are compact signatures, others BIP-322 simple signatures (a serialized witness).
+func (v *BitcoinVerifier) Verify(challenge, signature, address string) (*Result, error) {
+	addr, err := parseBitcoinAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, fmt.Errorf("Bitcoin signature isn't base64: %v", err)
+	}
+	message := []byte(challenge)
+	result := &Result{Address: address}
+
+	if len(sig) == 65 && sig[0] >= 27 && sig[0] <= 42 {
+		authorized, err := verifyBIP137(addr, message, sig)
+		if err != nil {
+			return nil, err
+		}
+		if authorized {
+			result.Authorized, result.Method = true, MethodBIP137
+		}
+		return result, nil
+	}
+
+	authorized, err := verifyBIP322Simple(addr, message, sig)
+	if err != nil {
+		return nil, err
+	}
+	if authorized {
+		result.Authorized, result.Method = true, MethodBIP322
+	}
+	return result, nil
+}
+
+// bitcoinMessageHash is the double SHA-256 of the message with the Bitcoin signed message prefix.
+func bitcoinMessageHash(message []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(bitcoinMessagePrefix)
+	writeVarInt(&buf, uint64(len(message)))
+	buf.Write(message)
+	return doubleSHA256(buf.Bytes())
+}
+
+// verifyBIP137 recovers the key of a compact signature and checks it against the address.
+// Like most wallets, the address type is taken from the address, the header only sets the recovery id and key compression.
+func verifyBIP137(addr *bitcoinAddress, message []byte, sig []byte) (bool, error) {
+	header := sig[0] - 27
+	compressed := header >= 4
+	recoverySig := append(append([]byte{}, sig[1:]...), header%4)
+
+	publicKey, err := ethCrypto.SigToPub(bitcoinMessageHash(message), recoverySig)
+	if err != nil {
+		return false, nil
+	}
+	serialized := ethCrypto.FromECDSAPub(publicKey)
+	if compressed {
+		serialized = ethCrypto.CompressPubkey(publicKey)
+	}
+
+	switch addr.typ {
+	case bitcoinP2PKH:
+		return bytes.Equal(hash160(serialized), addr.program), nil
+	case bitcoinP2SH:
+		redeemScript := append([]byte{0x00, 0x14}, hash160(serialized)...)
+		return compressed && bytes.Equal(hash160(redeemScript), addr.program), nil
+	case bitcoinP2WPKH:
+		return compressed && bytes.Equal(hash160(serialized), addr.program), nil
+	default:
+		return false, fmt.Errorf("BIP-137 signatures of %s addresses aren't supported", addr.typ)
+	}
+}
+
+// verifyBIP322Simple checks the witness of the virtual to_sign transaction spending the to_spend output of the address.
+func verifyBIP322Simple(addr *bitcoinAddress, message []byte, sig []byte) (bool, error) {
+	witness, err := parseWitness(sig)
+	if err != nil {
+		return false, fmt.Errorf("invalid BIP-322 signature: %v", err)
+	}
+
+	scriptPubKey := addr.scriptPubKey()
+	toSpendTxID := bip322ToSpendTxID(taggedHash("BIP0322-signed-message", message), scriptPubKey)
+
+	switch addr.typ {
+	case bitcoinP2WPKH:
+		if len(witness) != 2 || len(witness[0]) < 2 || len(witness[1]) != 33 {
+			return false, fmt.Errorf("invalid BIP-322 P2WPKH witness")
+		}
+		derSig, hashType, publicKey := witness[0][:len(witness[0])-1], witness[0][len(witness[0])-1], witness[1]
+		if hashType != sighashAll {
+			return false, fmt.Errorf("unsupported sighash type %d", hashType)
+		}
+		if !bytes.Equal(hash160(publicKey), addr.program) {
+			return false, nil
+		}
+		key, err := ethCrypto.DecompressPubkey(publicKey)
+		if err != nil {
+			return false, nil
+		}
+		var ecdsaSig struct{ R, S *big.Int }
+		if rest, err := asn1.Unmarshal(derSig, &ecdsaSig); err != nil || len(rest) > 0 {
+			return false, nil
+		}
+		sighash := bip143Sighash(toSpendTxID, p2pkhScript(addr.program), hashType)
+		return ecdsa.Verify(key, sighash, ecdsaSig.R, ecdsaSig.S), nil
+
+	case bitcoinP2TR:
+		if len(witness) != 1 || (len(witness[0]) != 64 && len(witness[0]) != 65) {
+			return false, fmt.Errorf("invalid BIP-322 P2TR key path witness")
+		}
+		schnorrSig, hashType := witness[0], byte(sighashDefault)
+		if len(schnorrSig) == 65 {
+			schnorrSig, hashType = schnorrSig[:64], schnorrSig[64]
+			if hashType != sighashAll {
+				return false, fmt.Errorf("unsupported sighash type %d", hashType)
+			}
+		}
+		sighash := bip341Sighash(toSpendTxID, scriptPubKey, hashType)
+		return verifySchnorr(addr.program, sighash, schnorrSig), nil
+
+	default:
+		return false, fmt.Errorf("BIP-322 simple signatures of %s addresses aren't supported", addr.typ)
+	}
+}
+
+// bip322ToSpendTxID returns the txid of the virtual to_spend transaction, in internal byte order.
+func bip322ToSpendTxID(messageHash []byte, scriptPubKey []byte) []byte {
+	var tx bytes.Buffer
+	tx.Write(make([]byte, 4)) // version 0
+	writeVarInt(&tx, 1)
+	tx.Write(make([]byte, 32)) // prevout hash
+	tx.Write([]byte{0xff, 0xff, 0xff, 0xff})
+	writeVarBytes(&tx, append([]byte{0x00, 0x20}, messageHash...)) // OP_0 PUSH32[message_hash]
+	tx.Write(make([]byte, 4))                                      // sequence 0
+	writeVarInt(&tx, 1)
+	tx.Write(make([]byte, 8)) // value 0
+	writeVarBytes(&tx, scriptPubKey)
+	tx.Write(make([]byte, 4)) // locktime 0
+	return doubleSHA256(tx.Bytes())
+}
+
+// toSignOutput is the single OP_RETURN output of the virtual to_sign transaction.
+func toSignOutput() []byte {
+	var output bytes.Buffer
+	output.Write(make([]byte, 8))
+	writeVarBytes(&output, []byte{0x6a})
+	return output.Bytes()
+}
+
+// bip143Sighash is the segwit v0 signature hash of the to_sign input, whose prevout holds 0 sats.
+func bip143Sighash(toSpendTxID []byte, scriptCode []byte, hashType byte) []byte {
+	outpoint := append(append([]byte{}, toSpendTxID...), 0, 0, 0, 0)
+
+	var preimage bytes.Buffer
+	preimage.Write(make([]byte, 4))               // version 0
+	preimage.Write(doubleSHA256(outpoint))        // hashPrevouts
+	preimage.Write(doubleSHA256(make([]byte, 4))) // hashSequence
+	preimage.Write(outpoint)                      // outpoint
+	writeVarBytes(&preimage, scriptCode)          // scriptCode
+	preimage.Write(make([]byte, 8))               // amount 0
+	preimage.Write(make([]byte, 4))               // sequence 0
+	preimage.Write(doubleSHA256(toSignOutput()))  // hashOutputs
+	preimage.Write(make([]byte, 4))               // locktime 0
+	binary.Write(&preimage, binary.LittleEndian, uint32(hashType))
+	return doubleSHA256(preimage.Bytes())
+}
+
+// bip341Sighash is the taproot key path signature hash of the to_sign input, whose prevout holds 0 sats.
+func bip341Sighash(toSpendTxID []byte, scriptPubKey []byte, hashType byte) []byte {
+	outpoint := append(append([]byte{}, toSpendTxID...), 0, 0, 0, 0)
+	var scriptPubKeys bytes.Buffer
+	writeVarBytes(&scriptPubKeys, scriptPubKey)
+
+	single := func(b []byte) []byte {
+		sum := sha256.Sum256(b)
+		return sum[:]
+	}
+
+	var msg bytes.Buffer
+	msg.WriteByte(0x00) // epoch
+	msg.WriteByte(hashType)
+	msg.Write(make([]byte, 4))               // version 0
+	msg.Write(make([]byte, 4))               // locktime 0
+	msg.Write(single(outpoint))              // sha_prevouts
+	msg.Write(single(make([]byte, 8)))       // sha_amounts
+	msg.Write(single(scriptPubKeys.Bytes())) // sha_scriptpubkeys
+	msg.Write(single(make([]byte, 4)))       // sha_sequences
+	msg.Write(single(toSignOutput()))        // sha_outputs
+	msg.WriteByte(0x00)                      // spend_type: key path, no annex
+	msg.Write(make([]byte, 4))               // input index 0
+	return taggedHash("TapSighash", msg.Bytes())
+}
+
+// parseWitness decodes a serialized witness stack.
+func parseWitness(b []byte) ([][]byte, error) {
+	r := bytes.NewReader(b)
+	count, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if count > uint64(len(b)) {
+		return nil, fmt.Errorf("witness has too many items")
+	}
+	witness := make([][]byte, count)
+	for i := range witness {
+		size, err := readVarInt(r)
+		if err != nil {
+			return nil, err
+		}
+		if size > uint64(r.Len()) {
+			return nil, fmt.Errorf("witness item exceeds the data")
+		}
+		witness[i] = make([]byte, size)
+		r.Read(witness[i])
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("witness has trailing data")
+	}
+	return witness, nil
+}
+
+func writeVarInt(buf *bytes.Buffer, n uint64) {
+	switch {
+	case n < 0xfd:
+		buf.WriteByte(byte(n))
+	case n <= 0xffff:
+		buf.WriteByte(0xfd)
+		binary.Write(buf, binary.LittleEndian, uint16(n))
+	case n <= 0xffffffff:
+		buf.WriteByte(0xfe)
+		binary.Write(buf, binary.LittleEndian, uint32(n))
+	default:
+		buf.WriteByte(0xff)
+		binary.Write(buf, binary.LittleEndian, n)
+	}
+}
+
+func writeVarBytes(buf *bytes.Buffer, b []byte) {
+	writeVarInt(buf, uint64(len(b)))
+	buf.Write(b)
+}
+
+func readVarInt(r *bytes.Reader) (uint64, error) {
+	prefix, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	switch prefix {
+	case 0xfd:
+		var n uint16
+		err = binary.Read(r, binary.LittleEndian, &n)
+		return uint64(n), err
+	case 0xfe:
+		var n uint32
+		err = binary.Read(r, binary.LittleEndian, &n)
+		return uint64(n), err
+	case 0xff:
+		var n uint64
+		err = binary.Read(r, binary.LittleEndian, &n)
+		return n, err
+	default:
+		return uint64(prefix), nil
+	}
+}
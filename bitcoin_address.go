@@ -0,0 +1,252 @@
+package dappauth
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ripemd160"
+)
+
+// bitcoinAddressType is the output type of a Bitcoin address.
+type bitcoinAddressType string
+
+const (
+	bitcoinP2PKH  bitcoinAddressType = "p2pkh"
+	bitcoinP2SH   bitcoinAddressType = "p2sh"
+	bitcoinP2WPKH bitcoinAddressType = "p2wpkh"
+	bitcoinP2WSH  bitcoinAddressType = "p2wsh"
+	bitcoinP2TR   bitcoinAddressType = "p2tr"
+)
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+// bitcoinAddress is a decoded mainnet, testnet or regtest Bitcoin address.
+type bitcoinAddress struct {
+	typ     bitcoinAddressType
+	program []byte // The hash of the legacy address, or the witness program
+}
+
+// parseBitcoinAddress decodes base58check P2PKH and P2SH addresses, and bech32/bech32m segwit addresses.
+func parseBitcoinAddress(address string) (*bitcoinAddress, error) {
+	if hrp := strings.ToLower(address); strings.HasPrefix(hrp, "bc1") || strings.HasPrefix(hrp, "tb1") || strings.HasPrefix(hrp, "bcrt1") {
+		version, program, err := segwitDecode(address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Bitcoin address %q: %v", address, err)
+		}
+		switch {
+		case version == 0 && len(program) == 20:
+			return &bitcoinAddress{typ: bitcoinP2WPKH, program: program}, nil
+		case version == 0 && len(program) == 32:
+			return &bitcoinAddress{typ: bitcoinP2WSH, program: program}, nil
+		case version == 1 && len(program) == 32:
+			return &bitcoinAddress{typ: bitcoinP2TR, program: program}, nil
+		default:
+			return nil, fmt.Errorf("unsupported Bitcoin address %q: witness version %d with %d byte program", address, version, len(program))
+		}
+	}
+
+	version, payload, err := base58CheckDecode(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Bitcoin address %q: %v", address, err)
+	}
+	if len(payload) != 20 {
+		return nil, fmt.Errorf("invalid Bitcoin address %q: %d byte hash", address, len(payload))
+	}
+	switch version {
+	case 0x00, 0x6f:
+		return &bitcoinAddress{typ: bitcoinP2PKH, program: payload}, nil
+	case 0x05, 0xc4:
+		return &bitcoinAddress{typ: bitcoinP2SH, program: payload}, nil
+	default:
+		return nil, fmt.Errorf("unsupported Bitcoin address %q: version %d", address, version)
+	}
+}
+
+// scriptPubKey returns the output script of the address.
+func (a *bitcoinAddress) scriptPubKey() []byte {
+	switch a.typ {
+	case bitcoinP2PKH:
+		return p2pkhScript(a.program)
+	case bitcoinP2SH:
+		return append(append([]byte{0xa9, 0x14}, a.program...), 0x87)
+	case bitcoinP2TR:
+		return append([]byte{0x51, 0x20}, a.program...)
+	default:
+		return append([]byte{0x00, byte(len(a.program))}, a.program...)
+	}
+}
+
+func p2pkhScript(pubKeyHash []byte) []byte {
+	return append(append([]byte{0x76, 0xa9, 0x14}, pubKeyHash...), 0x88, 0xac)
+}
+
+func hash160(b []byte) []byte {
+	sum := sha256.Sum256(b)
+	hasher := ripemd160.New()
+	hasher.Write(sum[:])
+	return hasher.Sum(nil)
+}
+
+func doubleSHA256(b []byte) []byte {
+	first := sha256.Sum256(b)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+// taggedHash is the BIP-340 tagged hash sha256(sha256(tag) || sha256(tag) || msg).
+func taggedHash(tag string, msg ...[]byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	hasher := sha256.New()
+	hasher.Write(tagHash[:])
+	hasher.Write(tagHash[:])
+	for _, b := range msg {
+		hasher.Write(b)
+	}
+	return hasher.Sum(nil)
+}
+
+// base58CheckDecode decodes a base58check string into its version byte and payload.
+func base58CheckDecode(s string) (byte, []byte, error) {
+	decoded, err := base58Decode(s)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(decoded) < 5 {
+		return 0, nil, fmt.Errorf("base58check string too short")
+	}
+	data, checksum := decoded[:len(decoded)-4], decoded[len(decoded)-4:]
+	if !bytes.Equal(doubleSHA256(data)[:4], checksum) {
+		return 0, nil, fmt.Errorf("invalid base58check checksum")
+	}
+	return data[0], data[1:], nil
+}
+
+// base58CheckEncode encodes the version byte and payload as a base58check string.
+func base58CheckEncode(version byte, payload []byte) string {
+	data := append([]byte{version}, payload...)
+	return base58Encode(append(data, doubleSHA256(data)[:4]...))
+}
+
+// segwitDecode decodes a BIP-173 (witness version 0) or BIP-350 (witness version 1+) segwit address.
+func segwitDecode(address string) (byte, []byte, error) {
+	_, data, checksum, err := bech32Decode(address)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(data) < 1 {
+		return 0, nil, fmt.Errorf("invalid bech32 length")
+	}
+	version := data[0]
+	if (version == 0 && checksum != bech32Const) || (version != 0 && checksum != bech32mConst) || version > 16 {
+		return 0, nil, fmt.Errorf("invalid bech32 checksum")
+	}
+
+	program, err := convertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(program) < 2 || len(program) > 40 {
+		return 0, nil, fmt.Errorf("invalid witness program length %d", len(program))
+	}
+	return version, program, nil
+}
+
+// bech32Decode splits a bech32 or bech32m string into its human readable part and 5 bit data (without the checksum).
+// The returned checksum is bech32Const or bech32mConst for valid strings, the caller checks the one it expects.
+func bech32Decode(s string) (string, []byte, uint32, error) {
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, 0, fmt.Errorf("mixed case")
+	}
+	s = strings.ToLower(s)
+
+	separator := strings.LastIndexByte(s, '1')
+	if separator < 1 || separator+7 > len(s) || len(s) > 90 {
+		return "", nil, 0, fmt.Errorf("invalid bech32 length")
+	}
+	hrp := s[:separator]
+	data := make([]byte, 0, len(s)-separator-1)
+	for _, c := range s[separator+1:] {
+		index := strings.IndexRune(bech32Charset, c)
+		if index < 0 {
+			return "", nil, 0, fmt.Errorf("invalid bech32 character %q", c)
+		}
+		data = append(data, byte(index))
+	}
+
+	checksum := bech32Polymod(append(bech32HRPExpand(hrp), data...))
+	return hrp, data[:len(data)-6], checksum, nil
+}
+
+// bech32Encode encodes the human readable part and 5 bit data with the checksum of constant (bech32Const or bech32mConst).
+func bech32Encode(hrp string, data []byte, constant uint32) string {
+	polymod := bech32Polymod(append(append(bech32HRPExpand(hrp), data...), 0, 0, 0, 0, 0, 0)) ^ constant
+	var sb strings.Builder
+	sb.WriteString(hrp + "1")
+	for _, d := range data {
+		sb.WriteByte(bech32Charset[d])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(bech32Charset[polymod>>uint(5*(5-i))&31])
+	}
+	return sb.String()
+}
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	expanded := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+	expanded = append(expanded, 0)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]&31)
+	}
+	return expanded
+}
+
+// convertBits regroups the bits of data from groups of fromBits to groups of toBits.
+func convertBits(data []byte, fromBits, toBits uint, pad bool) ([]byte, error) {
+	acc, bits := uint32(0), uint(0)
+	maxv := uint32(1)<<toBits - 1
+	var converted []byte
+	for _, v := range data {
+		if uint32(v)>>fromBits != 0 {
+			return nil, fmt.Errorf("invalid data range")
+		}
+		acc = acc<<fromBits | uint32(v)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			converted = append(converted, byte(acc>>bits&maxv))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			converted = append(converted, byte(acc<<(toBits-bits)&maxv))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxv != 0 {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	return converted, nil
+}
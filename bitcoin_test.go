@@ -0,0 +1,158 @@
+package dappauth
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// segwitEncode encodes a segwit address, for deriving test addresses.
+func segwitEncode(hrp string, version byte, program []byte) string {
+	data, _ := convertBits(program, 8, 5, true)
+	constant := uint32(bech32Const)
+	if version > 0 {
+		constant = bech32mConst
+	}
+	return bech32Encode(hrp, append([]byte{version}, data...), constant)
+}
+
+func TestBitcoinAddresses(t *testing.T) {
+	// the addresses of private key 1
+	key, err := ethCrypto.HexToECDSA("0000000000000000000000000000000000000000000000000000000000000001")
+	checkError(err, t)
+	compressed := ethCrypto.CompressPubkey(&key.PublicKey)
+
+	expectString(base58CheckEncode(0x00, hash160(compressed)), "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH", t)
+	expectString(base58CheckEncode(0x00, hash160(ethCrypto.FromECDSAPub(&key.PublicKey))), "1EHNa6Q4Jz2uvNExL497mE43ikXhwF6kZm", t)
+	expectString(base58CheckEncode(0x05, hash160(append([]byte{0x00, 0x14}, hash160(compressed)...))), "3JvL6Ymt8MVWiCNHC7oWU6nLeHNJKLZGLN", t)
+	expectString(segwitEncode("bc", 0, hash160(compressed)), "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", t)
+
+	for address, typ := range map[string]bitcoinAddressType{
+		"1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH":                             bitcoinP2PKH,
+		"3JvL6Ymt8MVWiCNHC7oWU6nLeHNJKLZGLN":                             bitcoinP2SH,
+		"BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4":                     bitcoinP2WPKH,
+		"bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8lt3": bitcoinP2TR,
+	} {
+		addr, err := parseBitcoinAddress(address)
+		checkError(err, t)
+		expectString(string(addr.typ), string(typ), t)
+	}
+
+	for _, address := range []string{
+		"1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMh",         // bad checksum
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", // bad checksum
+		"bc1zw508d6qejxtdg4y5r3zarvaryvqyzf3du",      // bech32 checksum with witness version 2
+	} {
+		_, err := parseBitcoinAddress(address)
+		expectBool(err != nil, true, t)
+	}
+}
+
+func TestBitcoinVerifier(t *testing.T) {
+
+	verifier := NewBitcoinVerifier()
+
+	t.Run("BIP-322 message hashes should match the test vectors", func(t *testing.T) {
+		expectString(hex.EncodeToString(taggedHash("BIP0322-signed-message", []byte(""))), "c90c269c4f8fcbe6880f72a721ddfbf1914268a794cbb21cfafee13770ae19f1", t)
+		expectString(hex.EncodeToString(taggedHash("BIP0322-signed-message", []byte("Hello World"))), "f0eb03b1a75ac6d9847f55c624a99169b5dccba2a31f5b23bea77ba270de0a7a", t)
+	})
+
+	t.Run("BIP-322 P2WPKH signatures should match the test vectors", func(t *testing.T) {
+		address := "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l"
+		emptySig := "AkcwRAIgM2gBAQqvZX15ZiysmKmQpDrG83avLIT492QBzLnQIxYCIBaTpOaD20qRlEylyxFSeEA2ba9YOixpX8z46TSDtS40ASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI="
+		helloSig := "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI="
+
+		result, err := verifier.Verify("", emptySig, address)
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+		expectString(string(result.Method), string(MethodBIP322), t)
+
+		isAuthorizedSigner, err := verifier.IsAuthorizedSigner("Hello World", helloSig, address)
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, true, t)
+
+		isAuthorizedSigner, err = verifier.IsAuthorizedSigner("Hello World", emptySig, address)
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, false, t)
+	})
+
+	t.Run("BIP-322 P2TR signatures should match the test vectors", func(t *testing.T) {
+		address := "bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8lt3"
+		sig := "AUHd69PrJQEv+oKTfZ8l+WROBHuy9HKrbFCJu7U1iK2iiEy1vMU5EfMtjc+VSHM7aU0SDbak5IUZRVno2P5mjSafAQ=="
+
+		isAuthorizedSigner, err := verifier.IsAuthorizedSigner("Hello World", sig, address)
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, true, t)
+
+		isAuthorizedSigner, err = verifier.IsAuthorizedSigner("Hello World!", sig, address)
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, false, t)
+	})
+
+	t.Run("BIP-137 signatures should be verified for each address type", func(t *testing.T) {
+		key, err := ethCrypto.GenerateKey()
+		checkError(err, t)
+		compressed := ethCrypto.CompressPubkey(&key.PublicKey)
+		uncompressed := ethCrypto.FromECDSAPub(&key.PublicKey)
+
+		sign := func(message string, headerBase byte) string {
+			sig, err := ethCrypto.Sign(bitcoinMessageHash([]byte(message)), key)
+			checkError(err, t)
+			return base64.StdEncoding.EncodeToString(append([]byte{headerBase + sig[64]}, sig[:64]...))
+		}
+
+		cases := []struct {
+			address    string
+			headerBase byte
+		}{
+			{base58CheckEncode(0x00, hash160(uncompressed)), 27},
+			{base58CheckEncode(0x00, hash160(compressed)), 31},
+			{base58CheckEncode(0x05, hash160(append([]byte{0x00, 0x14}, hash160(compressed)...))), 35},
+			{segwitEncode("bc", 0, hash160(compressed)), 39},
+			// wallets that sign segwit addresses with the P2PKH header
+			{segwitEncode("bc", 0, hash160(compressed)), 31},
+		}
+		for _, c := range cases {
+			result, err := verifier.Verify("foo", sign("foo", c.headerBase), c.address)
+			checkError(err, t)
+			expectBool(result.Authorized, true, t)
+			expectString(string(result.Method), string(MethodBIP137), t)
+
+			result, err = verifier.Verify("bar", sign("foo", c.headerBase), c.address)
+			checkError(err, t)
+			expectBool(result.Authorized, false, t)
+		}
+
+		// hex challenges, such as random nonces, are signed as text
+		nonce := "0x4f3a9c2e1b7d"
+		result, err := verifier.Verify(nonce, sign(nonce, 31), base58CheckEncode(0x00, hash160(compressed)))
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+
+		// uncompressed keys have no segwit address
+		result, err = verifier.Verify("foo", sign("foo", 27), segwitEncode("bc", 0, hash160(compressed)))
+		checkError(err, t)
+		expectBool(result.Authorized, false, t)
+	})
+
+	t.Run("BIP-340 signatures should match the test vectors", func(t *testing.T) {
+		publicKey, _ := hex.DecodeString("F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9")
+		message := make([]byte, 32)
+		sig, _ := hex.DecodeString("E907831F80848D1069A5371B402410364BDF1C5F8307B0084C55F1CE2DCA821525F66A4A85EA8B71E482A74F382D2CE5EBEEE8FDB2172F477DF4900D310536C0")
+		expectBool(verifySchnorr(publicKey, message, sig), true, t)
+
+		sig[0] ^= 1
+		expectBool(verifySchnorr(publicKey, message, sig), false, t)
+	})
+
+	t.Run("Malformed signatures should error", func(t *testing.T) {
+		_, err := verifier.Verify("foo", "not base64!", "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l")
+		expectBool(err != nil, true, t)
+
+		_, err = verifier.Verify("foo", base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0xff}, 10)), "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l")
+		expectBool(err != nil, true, t)
+	})
+}
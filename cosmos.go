@@ -0,0 +1,143 @@
+package dappauth
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// CosmosPubKeySecp256k1 is the amino type of the secp256k1 public keys of Cosmos SDK accounts.
+const CosmosPubKeySecp256k1 = "tendermint/PubKeySecp256k1"
+
+// CosmosPubKey is an amino JSON public key.
+type CosmosPubKey struct {
+	Type  string `json:"type"`
+	Value string `json:"value"` // base64
+}
+
+// CosmosSignature is an amino JSON StdSignature, as returned by Keplr signArbitrary.
+type CosmosSignature struct {
+	PubKey    CosmosPubKey `json:"pub_key"`
+	Signature string       `json:"signature"` // base64 r||s
+}
+
+// CosmosVerifier verifies ADR-036 arbitrary message signatures of Cosmos SDK accounts.
+type CosmosVerifier struct{}
+
+// NewCosmosVerifier creates a new CosmosVerifier .
+func NewCosmosVerifier() *CosmosVerifier {
+	return &CosmosVerifier{}
+}
+
+// IsAuthorizedSigner checks if the ADR-036 signature (a StdSignature JSON object) of the challenge was signed by the bech32 address, like Authenticator.IsAuthorizedSigner .
+func (v *CosmosVerifier) IsAuthorizedSigner(challenge, signature, address string) (bool, error) {
+	result, err := v.Verify(challenge, signature, address)
+	if err != nil {
+		return false, err
+	}
+	return result.Authorized, nil
+}
+
+// Verify checks if the ADR-036 signature (a StdSignature JSON object) of the challenge was signed by the bech32 address.
+// The address can have any prefix, and must be the hash of the public key in the signature.
+// Like Keplr's signArbitrary, the signed data is the text of the challenge, so hex challenges are not decoded.
+func (v *CosmosVerifier) Verify(challenge, signature, address string) (*Result, error) {
+	hrp, accAddr, err := cosmosAddressDecode(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Cosmos address %q: %v", address, err)
+	}
+
+	var sig CosmosSignature
+	if err := json.Unmarshal([]byte(signature), &sig); err != nil {
+		return nil, fmt.Errorf("Cosmos signature isn't a StdSignature JSON object: %v", err)
+	}
+	if sig.PubKey.Type != CosmosPubKeySecp256k1 {
+		return nil, fmt.Errorf("unsupported Cosmos public key type %q", sig.PubKey.Type)
+	}
+	pubKey, err := base64.StdEncoding.DecodeString(sig.PubKey.Value)
+	if err != nil || len(pubKey) != 33 {
+		return nil, fmt.Errorf("invalid Cosmos public key %q", sig.PubKey.Value)
+	}
+	sigBytes, err := base64.StdEncoding.DecodeString(sig.Signature)
+	if err != nil || len(sigBytes) != 64 {
+		return nil, fmt.Errorf("invalid Cosmos signature %q", sig.Signature)
+	}
+
+	result := &Result{Address: cosmosAddressEncode(hrp, accAddr)}
+	if !bytes.Equal(hash160(pubKey), accAddr) {
+		return result, nil
+	}
+
+	signDoc, err := adr036SignDoc(result.Address, []byte(challenge))
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(signDoc)
+	if ethCrypto.VerifySignature(pubKey, digest[:], sigBytes) {
+		result.Authorized, result.Method = true, MethodADR036
+	}
+	return result, nil
+}
+
+// adr036SignDoc is the amino JSON sign doc of an ADR-036 MsgSignData of the data by the signer: sorted keys, no chain ID, fee, memo, account number or sequence.
+func adr036SignDoc(signer string, data []byte) ([]byte, error) {
+	type msgSignDataValue struct {
+		Data   string `json:"data"`
+		Signer string `json:"signer"`
+	}
+	type msgSignData struct {
+		Type  string           `json:"type"`
+		Value msgSignDataValue `json:"value"`
+	}
+	type fee struct {
+		Amount []struct{} `json:"amount"`
+		Gas    string     `json:"gas"`
+	}
+	// the fields are in alphabetical order, as amino JSON sorts keys
+	signDoc := struct {
+		AccountNumber string        `json:"account_number"`
+		ChainID       string        `json:"chain_id"`
+		Fee           fee           `json:"fee"`
+		Memo          string        `json:"memo"`
+		Msgs          []msgSignData `json:"msgs"`
+		Sequence      string        `json:"sequence"`
+	}{
+		AccountNumber: "0",
+		Fee:           fee{Amount: []struct{}{}, Gas: "0"},
+		Msgs: []msgSignData{{
+			Type:  "sign/MsgSignData",
+			Value: msgSignDataValue{Data: base64.StdEncoding.EncodeToString(data), Signer: signer},
+		}},
+		Sequence: "0",
+	}
+	return json.Marshal(signDoc)
+}
+
+// cosmosAddressDecode decodes a bech32 Cosmos SDK account address into its prefix and 20 byte account address.
+func cosmosAddressDecode(address string) (string, []byte, error) {
+	hrp, data, checksum, err := bech32Decode(address)
+	if err != nil {
+		return "", nil, err
+	}
+	if checksum != bech32Const {
+		return "", nil, fmt.Errorf("invalid bech32 checksum")
+	}
+	accAddr, err := convertBits(data, 5, 8, false)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(accAddr) != 20 {
+		return "", nil, fmt.Errorf("invalid account address length %d", len(accAddr))
+	}
+	return hrp, accAddr, nil
+}
+
+// cosmosAddressEncode encodes the account address as a bech32 address with the prefix.
+func cosmosAddressEncode(hrp string, accAddr []byte) string {
+	data, _ := convertBits(accAddr, 8, 5, true)
+	return bech32Encode(hrp, data, bech32Const)
+}
@@ -0,0 +1,111 @@
+package dappauth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestCosmosVerifier(t *testing.T) {
+
+	verifier := NewCosmosVerifier()
+	key, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+	pubKey := ethCrypto.CompressPubkey(&key.PublicKey)
+	address := cosmosAddressEncode("cosmos", hash160(pubKey))
+
+	signArbitrary := func(signer string, data []byte) string {
+		signDoc, err := adr036SignDoc(signer, data)
+		checkError(err, t)
+		digest := sha256.Sum256(signDoc)
+		sig, err := ethCrypto.Sign(digest[:], key)
+		checkError(err, t)
+		stdSig, err := json.Marshal(CosmosSignature{
+			PubKey:    CosmosPubKey{Type: CosmosPubKeySecp256k1, Value: base64.StdEncoding.EncodeToString(pubKey)},
+			Signature: base64.StdEncoding.EncodeToString(sig[:64]),
+		})
+		checkError(err, t)
+		return string(stdSig)
+	}
+
+	t.Run("The sign doc should be the sorted amino JSON of MsgSignData", func(t *testing.T) {
+		signDoc, err := adr036SignDoc("cosmos1signer", []byte("foo"))
+		checkError(err, t)
+		expectString(string(signDoc), `{"account_number":"0","chain_id":"","fee":{"amount":[],"gas":"0"},"memo":"","msgs":[{"type":"sign/MsgSignData","value":{"data":"Zm9v","signer":"cosmos1signer"}}],"sequence":"0"}`, t)
+	})
+
+	t.Run("Addresses should round trip with any prefix", func(t *testing.T) {
+		for _, hrp := range []string{"cosmos", "osmo", "juno"} {
+			decodedHRP, accAddr, err := cosmosAddressDecode(cosmosAddressEncode(hrp, hash160(pubKey)))
+			checkError(err, t)
+			expectString(decodedHRP, hrp, t)
+			expectBool(string(accAddr) == string(hash160(pubKey)), true, t)
+		}
+
+		// a changed last character always breaks the checksum
+		last := "q"
+		if strings.HasSuffix(address, last) {
+			last = "p"
+		}
+		_, _, err := cosmosAddressDecode(address[:len(address)-1] + last)
+		expectBool(err != nil, true, t)
+		_, _, err = cosmosAddressDecode(segwitEncode("cosmos", 1, hash160(pubKey)))
+		expectBool(err != nil, true, t)
+	})
+
+	t.Run("Signatures of the address should be authorized", func(t *testing.T) {
+		result, err := verifier.Verify("foo", signArbitrary(address, []byte("foo")), address)
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+		expectString(string(result.Method), string(MethodADR036), t)
+		expectString(result.Address, address, t)
+
+		osmoAddress := cosmosAddressEncode("osmo", hash160(pubKey))
+		isAuthorizedSigner, err := verifier.IsAuthorizedSigner("foo", signArbitrary(osmoAddress, []byte("foo")), osmoAddress)
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, true, t)
+
+		// hex challenges are signed as text
+		isAuthorizedSigner, err = verifier.IsAuthorizedSigner("0x666f6f", signArbitrary(address, []byte("0x666f6f")), address)
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, true, t)
+	})
+
+	t.Run("Signatures of other challenges, signers or keys should not be authorized", func(t *testing.T) {
+		isAuthorizedSigner, err := verifier.IsAuthorizedSigner("bar", signArbitrary(address, []byte("foo")), address)
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, false, t)
+
+		// the hex of the signed text is another challenge
+		isAuthorizedSigner, err = verifier.IsAuthorizedSigner("0x666f6f", signArbitrary(address, []byte("foo")), address)
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, false, t)
+
+		// signed for the same key under another prefix
+		isAuthorizedSigner, err = verifier.IsAuthorizedSigner("foo", signArbitrary(cosmosAddressEncode("osmo", hash160(pubKey)), []byte("foo")), address)
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, false, t)
+
+		otherKey, err := ethCrypto.GenerateKey()
+		checkError(err, t)
+		otherAddress := cosmosAddressEncode("cosmos", hash160(ethCrypto.CompressPubkey(&otherKey.PublicKey)))
+		isAuthorizedSigner, err = verifier.IsAuthorizedSigner("foo", signArbitrary(otherAddress, []byte("foo")), otherAddress)
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, false, t)
+	})
+
+	t.Run("Malformed signatures should error", func(t *testing.T) {
+		_, err := verifier.Verify("foo", "not json", address)
+		expectBool(err != nil, true, t)
+
+		_, err = verifier.Verify("foo", `{"pub_key":{"type":"tendermint/PubKeyEd25519","value":""},"signature":""}`, address)
+		expectBool(err != nil, true, t)
+
+		_, err = verifier.Verify("foo", signArbitrary(address, []byte("foo")), "0x0000000000000000000000000000000000000000")
+		expectBool(err != nil, true, t)
+	})
+}
@@ -9,7 +9,6 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/dapperlabs/dappauth/ERCs"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	ethCrypto "github.com/ethereum/go-ethereum/crypto"
@@ -21,72 +20,210 @@ var (
 
 // Authenticator is the instance that holds the ethclient.Client .
 type Authenticator struct {
-	cc  bind.ContractCaller
-	ctx context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+	cc                 bind.ContractCaller
+	ctx                context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+	delegationPolicy   DelegationPolicy
+	ens                *ENSResolver // Resolves ENS names given as address (nil = disabled)
+	ensVerifyReverse   bool
+	ensProfile         *ENSResolver // Looks up the primary ENS name of authorized addresses (nil = disabled)
+	delegateRegistries []delegateRegistry
+	hashMode           HashMode
+	contractCall       ContractCallConfig
+	walletAllowlist    *WalletAllowlist // Wallet implementations allowed on the ERC-1271 path (nil = all)
+	walletRegistry     *WalletRegistry  // Identifies the wallets of the contract path (nil = disabled)
+	errorRegistry      *ErrorRegistry   // Decodes custom errors of reverted ERC-1271 calls (nil = none)
+	returnDecoding     ReturnDecoding
+	explanation        *Explanation // Records the steps of an Explain call (nil = not explaining)
+	chains             []Chain      // Other chains probed for contract wallets
+	messageProfile     MessageProfile
 }
 
+// Option configures an Authenticator .
+type Option func(*Authenticator)
+
 // NewAuthenticator creates a new Authenticator .
-func NewAuthenticator(ctx context.Context, cc bind.ContractCaller) *Authenticator {
-	return &Authenticator{
+func NewAuthenticator(ctx context.Context, cc bind.ContractCaller, opts ...Option) *Authenticator {
+	// go-ethereum's clients don't accept a nil context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	a := &Authenticator{
 		ctx: ctx,
 		cc:  cc,
 	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
 }
 
 // IsAuthorizedSigner implements the logic to check if an address is an authorized signer for a signature and challenge.
 func (a *Authenticator) IsAuthorizedSigner(challenge, signature, addrHex string) (bool, error) {
+	result, err := a.Verify(challenge, signature, addrHex)
+	if err != nil {
+		return false, err
+	}
+	return result.Authorized, nil
+}
+
+// Verify implements the same logic as IsAuthorizedSigner, but returns a Result detailing how the signature was authorized.
+func (a *Authenticator) Verify(challenge, signature, addrHex string) (*Result, error) {
+	result, err := a.verify(challenge, signature, addrHex)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Authorized && a.ensProfile != nil {
+		result.ENSProfile, result.ENSProfileErr = a.ensProfile.Profile(common.HexToAddress(result.Address))
+	}
+
+	// the contract path ran unless another flow authorized
+	if (!result.Authorized || result.Method == MethodERC1271) && a.walletRegistry != nil {
+		result.Wallet, result.WalletErr = a.detectWallet(common.HexToAddress(result.Address))
+	}
+
+	if a.messageProfile.FormatAddress != nil {
+		result.Address = a.messageProfile.formatAddress(common.HexToAddress(result.Address))
+	}
 
-	addr := common.HexToAddress(addrHex)
-	origSigBytes := common.FromHex(signature)
+	return result, nil
+}
+
+func (a *Authenticator) verify(challenge, signature, addrHex string) (*Result, error) {
+
+	addr, name, err := a.resolveSubject(addrHex)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy := a.strategy()
+
+	// EIP-7702 delegated EOAs can validate either way, so the order is set by the delegation policy
+	var delegate *common.Address
+	if a.delegationPolicy != DelegationPolicyNone {
+		delegate, err = a.delegateOf(addr)
+		if err != nil {
+			return nil, err
+		}
+		if delegate != nil && a.delegationPolicy != DelegationPolicyECDSAFirst {
+			strategy = a.delegatedStrategy()
+		}
+	}
+
+	result, err := strategy.Verify(challenge, signature, a.messageProfile.formatAddress(addr))
+	if err != nil {
+		return nil, err
+	}
+	result.Address, result.ENSName, result.DelegatedTo = addr.Hex(), name, delegate
+
+	return result, nil
+}
 
+// strategy is the default flow: the key of the address, then hot wallets delegated by the address, then the contract wallet.
+// The EOA check is expected to error on contract wallet signatures (e.g. "invalid signature length" of multi sigs),
+// and a registry error only fails the check if the contract wallet flow fails as well.
+func (a *Authenticator) strategy() *Strategy {
+	strategy := NewStrategy(StrategyAny, &EOAVerifier{a: a}, &delegateRegistryVerifier{a: a}, &ERC1271Verifier{a: a})
+	strategy.mergeErrors = func(errs []error) error {
+		errEOA, errRegistry, errCA := errs[0], errs[1], errs[2]
+		if errRegistry != nil {
+			return mergeRegistryErrors(errRegistry, errCA)
+		}
+		if errCA != nil {
+			return mergeErrors(errEOA, errCA)
+		}
+		return nil
+	}
+	return strategy
+}
+
+// checkEOA checks if the signature was signed by the key of the address.
+func (a *Authenticator) checkEOA(challenge string, origSigBytes []byte, addr common.Address) (bool, error) {
+	recoveredAddress, err := recoverEOA(a.messageProfile.messagePrefix(), challenge, origSigBytes)
+	if a.explanation != nil {
+		step := ExplainStep{Type: ExplainStepEOA, Hash: prefixedMessageHash(a.messageProfile.messagePrefix(), challenge), Err: errString(err)}
+		if err == nil {
+			step.Address = &recoveredAddress
+		}
+		a.explain(step)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Compare(addr.Bytes(), recoveredAddress.Bytes()) == 0, nil
+}
+
+// recoverEOA recovers the address of the key that signed the challenge with the personal message prefix.
+func recoverEOA(prefix, challenge string, origSigBytes []byte) (common.Address, error) {
+	if len(origSigBytes) != 65 {
+		return common.Address{}, fmt.Errorf("invalid signature length %d, expected 65", len(origSigBytes))
+	}
 	adjSigBytes := make([]byte, len(origSigBytes))
 	copy(adjSigBytes, origSigBytes)
 	adjSigBytes[64] -= 27 // Transform V from 27/28 to 0/1 according to the yellow paper
 
 	// retrieve public key from signature
 	var personalChallengeHash []byte
-	personalChallengeHash = personalMessageHash(challenge)
-
-	// error is expected when multi sig ("invalid signature length")
-	recoveredKey, errEOA := ethCrypto.SigToPub(personalChallengeHash, adjSigBytes)
+	personalChallengeHash = prefixedMessageHash(prefix, challenge)
 
-	// procced with EOA check if no error
-	if errEOA == nil {
-		recoveredAddress := ethCrypto.PubkeyToAddress(*recoveredKey)
+	recoveredKey, err := ethCrypto.SigToPub(personalChallengeHash, adjSigBytes)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return ethCrypto.PubkeyToAddress(*recoveredKey), nil
+}
 
-		// try direct-keyed wallet
-		if bytes.Compare(addr.Bytes(), recoveredAddress.Bytes()) == 0 {
-			return true, nil
+// checkContract checks if the contract at the address approves the signature via ERC-1271, returning the hash mode and return shape that validated.
+// With several hash modes to try, it only errors if none of them returned an answer. Running out of gas is an answer.
+func (a *Authenticator) checkContract(challenge string, origSigBytes []byte, addr common.Address) (bool, HashMode, ReturnShape, error) {
+	if a.walletAllowlist != nil {
+		allowed, err := a.walletAllowed(addr)
+		if err != nil || !allowed {
+			return false, "", "", err
 		}
 	}
+	a.explainCode(addr)
 
-	// try smart-contract wallet
-	_ERC1271Caller, errCA := ERCs.NewERC1271Caller(addr, a.cc)
-	if errCA != nil {
-		return false, mergeErrors(errEOA, errCA)
-	}
+	var firstErr error
+	answered := false
+	for _, hashMode := range a.hashMode.hashModes() {
+		// by default we send just a regular hash, which then the smart contract hashes ontop to an erc191 hash
+		hash, err := erc1271Hash(challenge, hashMode)
+		if err != nil {
+			a.explain(ExplainStep{Type: ExplainStepHash, HashMode: hashMode, Err: err.Error()})
+		} else {
+			a.explain(ExplainStep{Type: ExplainStepHash, HashMode: hashMode, Hash: hash[:]})
+			var returnShape ReturnShape
+			returnShape, err = a.isValidSignature(addr, hash, origSigBytes)
+			if err == nil && returnShape != "" {
+				return true, hashMode, returnShape, nil
+			}
+			if err != nil && isOutOfGas(err) {
+				err = nil
+			}
+			if err != nil {
+				if revert := revertError(err, addr, a.errorRegistry); revert != nil {
+					err = revert
+				}
+			}
+		}
 
-	_ERC1271CallerSession := ERCs.ERC1271CallerSession{
-		Contract: _ERC1271Caller,
-		CallOpts: bind.CallOpts{
-			Pending: false,
-			Context: a.ctx,
-		},
+		if err == nil {
+			answered = true
+		} else if firstErr == nil {
+			firstErr = err
+		}
 	}
 
-	// we send just a regular hash, which then the smart contract hashes ontop to an erc191 hash
-	magicValue, errCA := _ERC1271CallerSession.IsValidSignature(scMessageHash(challenge), origSigBytes)
-	if errCA != nil {
-		return false, mergeErrors(errEOA, errCA)
+	if answered {
+		return false, "", "", nil
 	}
-
-	return magicValue == _ERC1271MagicValue, nil
+	return false, "", "", firstErr
 }
 
 func personalMessageHash(challenge string) []byte {
-	b := decodeChallenge(challenge)
-	msg := fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(b), b)
-	return ethCrypto.Keccak256([]byte(msg))
+	return prefixedMessageHash(EthereumMessagePrefix, challenge)
 }
 
 // This is a hash just over the challenge. The smart contract takes this result and hashes on top to an erc191 hash.
@@ -115,5 +252,16 @@ func mergeErrors(errEOA error, errCA error) error {
 		msgEOA = fmt.Sprintf("errored with: '%v'", errEOA)
 	}
 
-	return fmt.Errorf("Authorisation check failed and errored in 2 alternative flows. 'External Owned Account' check %s. 'Contract Account' check errored with: '%v'", msgEOA, errCA)
+	return fmt.Errorf("Authorisation check failed and errored in 2 alternative flows. 'External Owned Account' check %s. 'Contract Account' check errored with: '%w'", msgEOA, errCA)
+}
+
+func mergeRegistryErrors(errRegistry error, errCA error) error {
+	var msgCA string
+	if errCA == nil {
+		msgCA = "returned false"
+	} else {
+		msgCA = fmt.Sprintf("errored with: '%v'", errCA)
+	}
+
+	return fmt.Errorf("Authorisation check failed and errored in the delegation registry flow. 'Contract Account' check %s. Registry check errored with: '%w'", msgCA, errRegistry)
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/dapperlabs/dappauth/dappauthtest"
 	"github.com/ethereum/go-ethereum/common"
 	ethCrypto "github.com/ethereum/go-ethereum/crypto"
 )
@@ -17,7 +18,8 @@ type dappauthTest struct {
 	challengeSign                 string // challengeSign should always equal to challenge unless testing for specific key recovery logic
 	signingKeys                   []*ecdsa.PrivateKey
 	authAddr                      common.Address
-	mockContract                  *mockContract
+	walletOwners                  []common.Address // Owners of the contract wallet at authAddr
+	walletReverts                 bool
 	expectedAuthorizedSignerError bool
 	expectedAuthorizedSigner      bool
 }
@@ -33,107 +35,77 @@ func TestDappAuth(t *testing.T) {
 
 	dappauthTests := []dappauthTest{
 		{
-			title:         "External wallets should be authorized signers over their address",
-			isEOA:         true,
-			challenge:     "foo",
-			challengeSign: "foo",
-			signingKeys:   []*ecdsa.PrivateKey{keyA},
-			authAddr:      ethCrypto.PubkeyToAddress(keyA.PublicKey),
-			mockContract: &mockContract{
-				address:               [20]byte{},
-				authorizedKey:         nil,
-				errorIsValidSignature: false,
-			},
+			title:                         "External wallets should be authorized signers over their address",
+			isEOA:                         true,
+			challenge:                     "foo",
+			challengeSign:                 "foo",
+			signingKeys:                   []*ecdsa.PrivateKey{keyA},
+			authAddr:                      ethCrypto.PubkeyToAddress(keyA.PublicKey),
 			expectedAuthorizedSignerError: false,
 			expectedAuthorizedSigner:      true,
 		},
 		{
-			title:         "External wallets should NOT be authorized signers when signing the wrong challenge",
-			isEOA:         true,
-			challenge:     "foo",
-			challengeSign: "bar",
-			signingKeys:   []*ecdsa.PrivateKey{keyA},
-			authAddr:      ethCrypto.PubkeyToAddress(keyA.PublicKey),
-			mockContract: &mockContract{
-				address:               [20]byte{},
-				authorizedKey:         nil,
-				errorIsValidSignature: false,
-			},
+			title:                         "External wallets should NOT be authorized signers when signing the wrong challenge",
+			isEOA:                         true,
+			challenge:                     "foo",
+			challengeSign:                 "bar",
+			signingKeys:                   []*ecdsa.PrivateKey{keyA},
+			authAddr:                      ethCrypto.PubkeyToAddress(keyA.PublicKey),
 			expectedAuthorizedSignerError: false,
 			expectedAuthorizedSigner:      false,
 		},
 		{
-			title:         "External wallets should NOT be authorized signers over OTHER addresses",
-			isEOA:         true,
-			challenge:     "foo",
-			challengeSign: "foo",
-			signingKeys:   []*ecdsa.PrivateKey{keyA},
-			authAddr:      ethCrypto.PubkeyToAddress(keyB.PublicKey),
-			mockContract: &mockContract{
-				address:               [20]byte{},
-				authorizedKey:         nil,
-				errorIsValidSignature: false,
-			},
+			title:                         "External wallets should NOT be authorized signers over OTHER addresses",
+			isEOA:                         true,
+			challenge:                     "foo",
+			challengeSign:                 "foo",
+			signingKeys:                   []*ecdsa.PrivateKey{keyA},
+			authAddr:                      ethCrypto.PubkeyToAddress(keyB.PublicKey),
 			expectedAuthorizedSignerError: false,
 			expectedAuthorizedSigner:      false,
 		},
 		{
-			title:         "Smart-contract wallets with a 1-of-1 correct internal key should be authorized signers over their address",
-			isEOA:         false,
-			challenge:     "foo",
-			challengeSign: "foo",
-			signingKeys:   []*ecdsa.PrivateKey{keyB},
-			authAddr:      ethCrypto.PubkeyToAddress(keyA.PublicKey),
-			mockContract: &mockContract{
-				address:               ethCrypto.PubkeyToAddress(keyA.PublicKey),
-				authorizedKey:         &keyB.PublicKey,
-				errorIsValidSignature: false,
-			},
+			title:                         "Smart-contract wallets with a 1-of-1 correct internal key should be authorized signers over their address",
+			isEOA:                         false,
+			challenge:                     "foo",
+			challengeSign:                 "foo",
+			signingKeys:                   []*ecdsa.PrivateKey{keyB},
+			authAddr:                      ethCrypto.PubkeyToAddress(keyA.PublicKey),
+			walletOwners:                  []common.Address{ethCrypto.PubkeyToAddress(keyB.PublicKey)},
 			expectedAuthorizedSignerError: false,
 			expectedAuthorizedSigner:      true,
 		},
 		{
-			title:         "Smart-contract wallets with a 1-of-2 correct internal key should be authorized signers over their address",
-			isEOA:         false,
-			challenge:     "foo",
-			challengeSign: "foo",
-			signingKeys:   []*ecdsa.PrivateKey{keyB, keyC},
-			authAddr:      ethCrypto.PubkeyToAddress(keyA.PublicKey),
-			mockContract: &mockContract{
-				address:               ethCrypto.PubkeyToAddress(keyA.PublicKey),
-				authorizedKey:         &keyB.PublicKey,
-				errorIsValidSignature: false,
-			},
+			title:                         "Smart-contract wallets with a 1-of-2 correct internal key should be authorized signers over their address",
+			isEOA:                         false,
+			challenge:                     "foo",
+			challengeSign:                 "foo",
+			signingKeys:                   []*ecdsa.PrivateKey{keyB, keyC},
+			authAddr:                      ethCrypto.PubkeyToAddress(keyA.PublicKey),
+			walletOwners:                  []common.Address{ethCrypto.PubkeyToAddress(keyB.PublicKey)},
 			expectedAuthorizedSignerError: false,
 			expectedAuthorizedSigner:      true,
 		},
 		{
-			title:         "Smart-contract wallets with a 1-of-1 incorrect internal key should NOT be authorized signers over their address",
-			isEOA:         false,
-			challenge:     "foo",
-			challengeSign: "foo",
-			signingKeys:   []*ecdsa.PrivateKey{keyB},
-			authAddr:      ethCrypto.PubkeyToAddress(keyA.PublicKey),
-			mockContract: &mockContract{
-				address:               ethCrypto.PubkeyToAddress(keyA.PublicKey),
-				authorizedKey:         &keyC.PublicKey,
-				errorIsValidSignature: false,
-			},
+			title:                         "Smart-contract wallets with a 1-of-1 incorrect internal key should NOT be authorized signers over their address",
+			isEOA:                         false,
+			challenge:                     "foo",
+			challengeSign:                 "foo",
+			signingKeys:                   []*ecdsa.PrivateKey{keyB},
+			authAddr:                      ethCrypto.PubkeyToAddress(keyA.PublicKey),
+			walletOwners:                  []common.Address{ethCrypto.PubkeyToAddress(keyC.PublicKey)},
 			expectedAuthorizedSignerError: false,
 			expectedAuthorizedSigner:      false,
 		},
 		{
-			title:         "IsAuthorizedSigner should error when smart-contract call errors",
-			isEOA:         false,
-			challenge:     "foo",
-			challengeSign: "foo",
-			signingKeys:   []*ecdsa.PrivateKey{keyB},
-			authAddr:      ethCrypto.PubkeyToAddress(keyA.PublicKey),
-			mockContract: &mockContract{
-				address:               ethCrypto.PubkeyToAddress(keyA.PublicKey),
-				authorizedKey:         &keyB.PublicKey,
-				errorIsValidSignature: true,
-			},
+			title:                         "IsAuthorizedSigner should error when smart-contract call errors",
+			isEOA:                         false,
+			challenge:                     "foo",
+			challengeSign:                 "foo",
+			signingKeys:                   []*ecdsa.PrivateKey{keyB},
+			authAddr:                      ethCrypto.PubkeyToAddress(keyA.PublicKey),
+			walletOwners:                  []common.Address{ethCrypto.PubkeyToAddress(keyB.PublicKey)},
+			walletReverts:                 true,
 			expectedAuthorizedSignerError: true,
 			expectedAuthorizedSigner:      false,
 		},
@@ -142,7 +114,11 @@ func TestDappAuth(t *testing.T) {
 	// iterate over main test cases
 	for _, test := range dappauthTests {
 		t.Run(test.title, func(t *testing.T) {
-			authenticator := NewAuthenticator(nil, test.mockContract)
+			// the wallets only check the first signature, extra signatures of non owners are tolerated
+			wallet := dappauthtest.NewWallet(test.authAddr, 1, test.walletOwners...)
+			wallet.IgnoreExtra = true
+			wallet.Revert = test.walletReverts
+			authenticator := NewAuthenticator(nil, wallet)
 
 			var sig string
 			for _, signingKey := range test.signingKeys {
@@ -156,11 +132,10 @@ func TestDappAuth(t *testing.T) {
 
 	// this test adds to test coverage
 	t.Run("Invalid signature should fail", func(t *testing.T) {
-		authenticator := NewAuthenticator(nil, &mockContract{
-			address:               [20]byte{},
-			authorizedKey:         nil,
-			errorIsValidSignature: false,
-		})
+		// like wallets recovering with OpenZeppelin's ECDSA, the wallet reverts on signatures that don't recover
+		wallet := dappauthtest.NewWallet(ethCrypto.PubkeyToAddress(keyA.PublicKey), 1, ethCrypto.PubkeyToAddress(keyB.PublicKey))
+		wallet.RevertOnInvalid = true
+		authenticator := NewAuthenticator(nil, wallet)
 
 		invalidSigBytes := [65]byte{}
 		invalidSig := hex.EncodeToString(invalidSigBytes[:])
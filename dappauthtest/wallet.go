@@ -0,0 +1,236 @@
+// Package dappauthtest provides a programmable fake contract wallet, to unit test ERC-1271 login flows without a node.
+package dappauthtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	ethAbi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	// MagicValue is the ERC-1271 magic value returned by isValidSignature(bytes32,bytes) when the signature is valid.
+	MagicValue = [4]byte{22, 38, 186, 126} // 0x1626ba7e
+
+	// PlaceholderCode is the code returned by CodeAt when Wallet.Code isn't set, so the address looks like a contract.
+	PlaceholderCode = []byte{0x60, 0x00, 0x60, 0x00, 0xfd} // PUSH1 0 PUSH1 0 REVERT
+
+	isValidSignatureArgs = mustArguments("bytes32", "bytes")
+	errorArgs            = mustArguments("string")
+)
+
+// Call is a recorded isValidSignature call.
+type Call struct {
+	From      common.Address
+	Gas       uint64
+	Hash      [32]byte // The hash argument
+	Signature []byte   // The signature argument
+}
+
+// Wallet is a fake ERC-1271 contract wallet at Address, implementing bind.ContractCaller .
+// isValidSignature(bytes32,bytes) recovers each 65 byte chunk of the signature from the EIP-191 version 0 hash
+// of the wallet (0x1900 ++ Address ++ hash), as dappauth.Signer SignForContract signs it, and returns MagicValue
+// when Threshold distinct Owners signed. Set the fields before use, the calls are safe for concurrent use.
+type Wallet struct {
+	Address         common.Address
+	Owners          []common.Address
+	Threshold       int           // The number of distinct owners that must sign (0 = 1)
+	IgnoreExtra     bool          // Whether the signatures after the first Threshold are ignored, like a Safe only reads the ones it needs
+	RevertOnInvalid bool          // Whether signatures that don't recover revert, like OpenZeppelin's ECDSA.recover, instead of returning false
+	Code            []byte        // Returned by CodeAt (nil = PlaceholderCode)
+	Revert          bool          // Whether isValidSignature reverts
+	RevertData      []byte        // The revert data when Revert is set, e.g. an ABI encoded Error(string)
+	ReturnData      []byte        // Returned by isValidSignature instead of its verdict (nil = the verdict)
+	Delay           time.Duration // Waited before answering, unless the context is done first
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+// NewWallet creates a new Wallet at address, authorizing threshold of the owners (m-of-n).
+func NewWallet(address common.Address, threshold int, owners ...common.Address) *Wallet {
+	return &Wallet{
+		Address:   address,
+		Owners:    owners,
+		Threshold: threshold,
+	}
+}
+
+// Calls returns the isValidSignature calls made so far, in order.
+func (w *Wallet) Calls() []Call {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]Call(nil), w.calls...)
+}
+
+// CodeAt implements bind.ContractCaller , returning Code for Address and no code for other addresses.
+func (w *Wallet) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	if err := w.wait(ctx); err != nil {
+		return nil, err
+	}
+	if contract != w.Address {
+		return nil, nil
+	}
+	if w.Code == nil {
+		return PlaceholderCode, nil
+	}
+	return w.Code, nil
+}
+
+// CallContract implements bind.ContractCaller , answering isValidSignature(bytes32,bytes) calls to Address.
+func (w *Wallet) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if err := w.wait(ctx); err != nil {
+		return nil, err
+	}
+	if call.To == nil || *call.To != w.Address {
+		return nil, nil
+	}
+	if len(call.Data) < 4 || !bytes.Equal(call.Data[:4], MagicValue[:]) {
+		return nil, fmt.Errorf("Unexpected method call %x", call.Data)
+	}
+
+	args, err := isValidSignatureArgs.Unpack(call.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	recorded := Call{
+		From:      call.From,
+		Gas:       call.Gas,
+		Hash:      args[0].([32]byte),
+		Signature: args[1].([]byte),
+	}
+	w.mu.Lock()
+	w.calls = append(w.calls, recorded)
+	w.mu.Unlock()
+
+	if w.Revert {
+		return nil, &RevertError{Data: w.RevertData}
+	}
+	if w.ReturnData != nil {
+		return w.ReturnData, nil
+	}
+
+	valid, err := w.isValidSignature(recorded.Hash, recorded.Signature)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]byte, 32)
+	if valid {
+		copy(result, MagicValue[:])
+	}
+	return result, nil
+}
+
+// isValidSignature checks that Threshold distinct owners signed the hash.
+func (w *Wallet) isValidSignature(hash [32]byte, signature []byte) (bool, error) {
+	threshold := w.Threshold
+	if threshold == 0 {
+		threshold = 1
+	}
+	if w.IgnoreExtra && len(signature) > threshold*65 {
+		signature = signature[:threshold*65]
+	}
+	if len(signature) == 0 || len(signature)%65 != 0 {
+		if w.RevertOnInvalid {
+			return false, revertWithReason("ECDSA: invalid signature length")
+		}
+		return false, nil
+	}
+
+	digest := ethCrypto.Keccak256([]byte{25, 0}, w.Address.Bytes(), hash[:])
+	signed := make(map[common.Address]bool)
+	for i := 0; i < len(signature); i += 65 {
+		sig := append([]byte(nil), signature[i:i+65]...)
+		sig[64] -= 27 // Transform V from 27/28 to 0/1 according to the yellow paper
+
+		key, err := ethCrypto.SigToPub(digest, sig)
+		if err != nil {
+			if w.RevertOnInvalid {
+				return false, revertWithReason("ECDSA: invalid signature")
+			}
+			return false, nil
+		}
+		signer := ethCrypto.PubkeyToAddress(*key)
+		if !w.isOwner(signer) {
+			return false, nil
+		}
+		signed[signer] = true
+	}
+	return len(signed) >= threshold, nil
+}
+
+func (w *Wallet) isOwner(addr common.Address) bool {
+	for _, owner := range w.Owners {
+		if owner == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// wait waits the Delay, or until the context is done.
+func (w *Wallet) wait(ctx context.Context) error {
+	if w.Delay == 0 {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timer := time.NewTimer(w.Delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RevertError is the error of a reverted call, carrying the revert data like the JSON-RPC errors of eth_call.
+type RevertError struct {
+	Data []byte
+}
+
+func (e *RevertError) Error() string {
+	return vm.ErrExecutionReverted.Error()
+}
+
+// Unwrap returns vm.ErrExecutionReverted .
+func (e *RevertError) Unwrap() error {
+	return vm.ErrExecutionReverted
+}
+
+// ErrorData implements rpc.DataError .
+func (e *RevertError) ErrorData() interface{} {
+	return hexutil.Encode(e.Data)
+}
+
+// revertWithReason is the RevertError of a Solidity require with the reason.
+func revertWithReason(reason string) *RevertError {
+	encoded, err := errorArgs.Pack(reason)
+	if err != nil {
+		panic(err)
+	}
+	return &RevertError{Data: append([]byte{0x08, 0xc3, 0x79, 0xa0}, encoded...)}
+}
+
+func mustArguments(types ...string) ethAbi.Arguments {
+	var args ethAbi.Arguments
+	for _, name := range types {
+		typ, err := ethAbi.NewType(name, "", nil)
+		if err != nil {
+			panic(err)
+		}
+		args = append(args, ethAbi.Argument{Type: typ})
+	}
+	return args
+}
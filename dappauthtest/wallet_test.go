@@ -0,0 +1,197 @@
+package dappauthtest_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/dapperlabs/dappauth"
+	"github.com/dapperlabs/dappauth/dappauthtest"
+	ethAbi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestWallet(t *testing.T) {
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	owners := make([]common.Address, 3)
+	for i := range keys {
+		key, err := ethCrypto.GenerateKey()
+		checkError(err, t)
+		keys[i], owners[i] = key, ethCrypto.PubkeyToAddress(key.PublicKey)
+	}
+	walletAddr := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	sign := func(challenge string, signers ...*ecdsa.PrivateKey) string {
+		var signatures []string
+		for _, key := range signers {
+			sig, err := dappauth.NewSigner(key).SignForContract(challenge, walletAddr, dappauth.HashModeRaw)
+			checkError(err, t)
+			signatures = append(signatures, sig)
+		}
+		multiSig, err := dappauth.ConcatSignatures(signatures...)
+		checkError(err, t)
+		return multiSig
+	}
+
+	t.Run("The threshold of distinct owners should authorize", func(t *testing.T) {
+		wallet := dappauthtest.NewWallet(walletAddr, 2, owners...)
+		authenticator := dappauth.NewAuthenticator(nil, wallet)
+
+		for _, test := range []struct {
+			title    string
+			signers  []*ecdsa.PrivateKey
+			expected bool
+		}{
+			{"2 of 3 owners", []*ecdsa.PrivateKey{keys[0], keys[1]}, true},
+			{"3 of 3 owners", []*ecdsa.PrivateKey{keys[0], keys[1], keys[2]}, true},
+			{"1 of 3 owners", []*ecdsa.PrivateKey{keys[2]}, false},
+			{"the same owner twice", []*ecdsa.PrivateKey{keys[0], keys[0]}, false},
+		} {
+			isAuthorizedSigner, err := authenticator.IsAuthorizedSigner("foo", sign("foo", test.signers...), walletAddr.Hex())
+			checkError(err, t)
+			if isAuthorizedSigner != test.expected {
+				t.Errorf("%s: expected %v", test.title, test.expected)
+			}
+		}
+
+		notOwner, err := ethCrypto.GenerateKey()
+		checkError(err, t)
+		isAuthorizedSigner, err := authenticator.IsAuthorizedSigner("foo", sign("foo", keys[0], notOwner), walletAddr.Hex())
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, false, t)
+	})
+
+	t.Run("Extra signatures should only be ignored when configured", func(t *testing.T) {
+		notOwner, err := ethCrypto.GenerateKey()
+		checkError(err, t)
+		wallet := dappauthtest.NewWallet(walletAddr, 1, owners[0])
+		authenticator := dappauth.NewAuthenticator(nil, wallet)
+
+		isAuthorizedSigner, err := authenticator.IsAuthorizedSigner("foo", sign("foo", keys[0], notOwner), walletAddr.Hex())
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, false, t)
+
+		wallet.IgnoreExtra = true
+		isAuthorizedSigner, err = authenticator.IsAuthorizedSigner("foo", sign("foo", keys[0], notOwner), walletAddr.Hex())
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, true, t)
+
+		// only the signatures after the threshold are ignored
+		isAuthorizedSigner, err = authenticator.IsAuthorizedSigner("foo", sign("foo", notOwner, keys[0]), walletAddr.Hex())
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, false, t)
+	})
+
+	t.Run("Unrecoverable signatures should only revert when configured", func(t *testing.T) {
+		wallet := dappauthtest.NewWallet(walletAddr, 1, owners[0])
+		invalidSig := "0x" + common.Bytes2Hex(make([]byte, 65))
+
+		result, err := dappauth.NewAuthenticator(nil, wallet).Verify("foo", invalidSig, walletAddr.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, false, t)
+
+		wallet.RevertOnInvalid = true
+		_, err = dappauth.NewAuthenticator(nil, wallet).Verify("foo", invalidSig, walletAddr.Hex())
+		var revert *dappauth.RevertError
+		expectBool(errors.As(err, &revert), true, t)
+		if revert != nil {
+			expectString(revert.Reason, "ECDSA: invalid signature", t)
+		}
+	})
+
+	t.Run("Calls should be recorded", func(t *testing.T) {
+		wallet := dappauthtest.NewWallet(walletAddr, 1, owners[0])
+		from := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+		authenticator := dappauth.NewAuthenticator(nil, wallet, dappauth.WithContractCall(dappauth.ContractCallConfig{From: from, Gas: 100000}))
+
+		signature := sign("foo", keys[0])
+		_, err := authenticator.IsAuthorizedSigner("foo", signature, walletAddr.Hex())
+		checkError(err, t)
+
+		calls := wallet.Calls()
+		expectBool(len(calls) == 1, true, t)
+		expectString(calls[0].From.Hex(), from.Hex(), t)
+		expectBool(calls[0].Gas == 100000, true, t)
+		expectString(common.Bytes2Hex(calls[0].Hash[:]), common.Bytes2Hex(ethCrypto.Keccak256([]byte("foo"))), t)
+		expectString("0x"+common.Bytes2Hex(calls[0].Signature), signature, t)
+
+		code, err := wallet.CodeAt(context.Background(), walletAddr, nil)
+		checkError(err, t)
+		expectString(common.Bytes2Hex(code), common.Bytes2Hex(dappauthtest.PlaceholderCode), t)
+	})
+
+	t.Run("Forced reverts should carry their revert data", func(t *testing.T) {
+		reason, err := ethAbi.NewType("string", "", nil)
+		checkError(err, t)
+		encoded, err := ethAbi.Arguments{{Type: reason}}.Pack("not an owner")
+		checkError(err, t)
+
+		wallet := dappauthtest.NewWallet(walletAddr, 1, owners[0])
+		wallet.Revert, wallet.RevertData = true, append([]byte{0x08, 0xc3, 0x79, 0xa0}, encoded...)
+
+		_, err = dappauth.NewAuthenticator(nil, wallet).Verify("foo", sign("foo", keys[0]), walletAddr.Hex())
+		var revert *dappauth.RevertError
+		expectBool(errors.As(err, &revert), true, t)
+		if revert != nil {
+			expectString(revert.Reason, "not an owner", t)
+		}
+	})
+
+	t.Run("Custom return data should replace the verdict", func(t *testing.T) {
+		wallet := dappauthtest.NewWallet(walletAddr, 1, owners[0])
+		wallet.ReturnData = common.LeftPadBytes(big.NewInt(1).Bytes(), 32) // a bool
+
+		result, err := dappauth.NewAuthenticator(nil, wallet).Verify("foo", sign("foo", keys[0]), walletAddr.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, false, t)
+
+		// legacy wallets returning true are accepted when tolerant, whoever signed
+		result, err = dappauth.NewAuthenticator(nil, wallet, dappauth.WithReturnDecoding(dappauth.ReturnDecodingTolerant)).Verify("foo", sign("foo", keys[2]), walletAddr.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+		expectString(string(result.ReturnShape), string(dappauth.ReturnShapeBool), t)
+
+		wallet.ReturnData = append(dappauthtest.MagicValue[:], make([]byte, 28)...)
+		result, err = dappauth.NewAuthenticator(nil, wallet).Verify("foo", sign("foo", keys[2]), walletAddr.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+	})
+
+	t.Run("Delays should honour the context", func(t *testing.T) {
+		wallet := dappauthtest.NewWallet(walletAddr, 1, owners[0])
+		wallet.Delay = time.Second
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := dappauth.NewAuthenticator(ctx, wallet).IsAuthorizedSigner("foo", sign("foo", keys[0]), walletAddr.Hex())
+		expectBool(errors.Is(err, context.DeadlineExceeded), true, t)
+
+		wallet.Delay = time.Millisecond
+		isAuthorizedSigner, err := dappauth.NewAuthenticator(context.Background(), wallet).IsAuthorizedSigner("foo", sign("foo", keys[0]), walletAddr.Hex())
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, true, t)
+	})
+}
+
+func checkError(err error, t *testing.T) {
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func expectBool(actual, expected bool, t *testing.T) {
+	if actual != expected {
+		t.Errorf("expected %v to be %v", actual, expected)
+	}
+}
+
+func expectString(actual, expected string, t *testing.T) {
+	if actual != expected {
+		t.Errorf("expected %s to be %s", actual, expected)
+	}
+}
@@ -0,0 +1,214 @@
+package dappauth
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/dapperlabs/dappauth/ERCs"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// DelegateRegistryV1Address is the address of the delegate.xyz v1 delegation registry.
+	DelegateRegistryV1Address = common.HexToAddress("0x00000000000076A84feF008CDAbe6409d2FE638B")
+	// DelegateRegistryV2Address is the address of the delegate.xyz v2 delegation registry.
+	DelegateRegistryV2Address = common.HexToAddress("0x00000000000000447e69651d841bD8D104Bed493")
+)
+
+// DelegateRegistryVersion is the interface version of a delegate.xyz style delegation registry.
+type DelegateRegistryVersion int
+
+const (
+	// DelegateRegistryV1 is the delegate.xyz v1 interface (checkDelegateForAll/Contract/Token).
+	DelegateRegistryV1 DelegateRegistryVersion = iota + 1
+	// DelegateRegistryV2 is the delegate.xyz v2 interface (checkDelegateForAll/Contract/ERC721, with rights).
+	DelegateRegistryV2
+)
+
+// DelegationType is the level a vault delegated its wallet at.
+type DelegationType string
+
+const (
+	// DelegationTypeAll is a wallet-wide delegation.
+	DelegationTypeAll DelegationType = "all"
+	// DelegationTypeContract is a delegation for a single contract.
+	DelegationTypeContract DelegationType = "contract"
+	// DelegationTypeToken is a delegation for a single token of a contract.
+	DelegationTypeToken DelegationType = "token"
+)
+
+// DelegationScope selects which delegations authorize a hot wallet for a vault.
+// Wallet-wide delegations always apply, contract and token delegations only if Contract and TokenID are set.
+type DelegationScope struct {
+	Contract *common.Address // Contract of contract and token delegations (nil = wallet-wide only)
+	TokenID  *big.Int        // Token of token delegations, requires Contract (nil = none)
+	Rights   [32]byte        // Rights required of v2 delegations (zero = full rights only)
+}
+
+// VaultDelegation is the registry delegation that authorized a hot wallet for a vault.
+type VaultDelegation struct {
+	Registry common.Address
+	Version  DelegateRegistryVersion
+	Type     DelegationType
+	Delegate common.Address // The hot wallet that signed
+	Vault    common.Address // The address that was verified
+	Contract *common.Address
+	TokenID  *big.Int
+	Rights   [32]byte // v2 only
+}
+
+type delegateRegistry struct {
+	address common.Address
+	version DelegateRegistryVersion
+	scope   DelegationScope
+}
+
+// WithDelegateRegistry authorizes signatures of hot wallets that hold a delegation for the verified vault address in the registry.
+// It can be passed multiple times (e.g. for v1 and v2), registries are checked in order.
+func WithDelegateRegistry(registry common.Address, version DelegateRegistryVersion, scope DelegationScope) Option {
+	return func(a *Authenticator) {
+		a.delegateRegistries = append(a.delegateRegistries, delegateRegistry{
+			address: registry,
+			version: version,
+			scope:   scope,
+		})
+	}
+}
+
+// checkDelegateRegistries returns the delegation held by the wallet that signed the challenge for the vault, or nil if there is none.
+func (a *Authenticator) checkDelegateRegistries(challenge string, origSigBytes []byte, vault common.Address) (*VaultDelegation, error) {
+	if len(a.delegateRegistries) == 0 {
+		return nil, nil
+	}
+
+	// only external wallets can act as hot wallets
+	hotWallet, err := recoverEOA(a.messageProfile.messagePrefix(), challenge, origSigBytes)
+	if err != nil || hotWallet == vault {
+		return nil, nil
+	}
+
+	callOpts := bind.CallOpts{
+		Pending: false,
+		Context: a.ctx,
+	}
+
+	for _, registry := range a.delegateRegistries {
+		delegationType, err := registry.check(&callOpts, a.cc, hotWallet, vault)
+		if err != nil {
+			return nil, fmt.Errorf("Delegation registry %s check errored with: '%v'", registry.address.Hex(), err)
+		}
+		if delegationType == "" {
+			continue
+		}
+
+		delegation := &VaultDelegation{
+			Registry: registry.address,
+			Version:  registry.version,
+			Type:     delegationType,
+			Delegate: hotWallet,
+			Vault:    vault,
+		}
+		if registry.version == DelegateRegistryV2 {
+			delegation.Rights = registry.scope.Rights
+		}
+		if delegationType != DelegationTypeAll {
+			delegation.Contract = registry.scope.Contract
+		}
+		if delegationType == DelegationTypeToken {
+			delegation.TokenID = registry.scope.TokenID
+		}
+		return delegation, nil
+	}
+
+	return nil, nil
+}
+
+// delegateRegistryVerifier verifies signatures of hot wallets delegated by the address in the registries of the Authenticator.
+type delegateRegistryVerifier struct {
+	a *Authenticator
+}
+
+// Verify checks if the signer of the challenge holds a delegation for the address.
+func (v *delegateRegistryVerifier) Verify(challenge, signature, addrHex string) (*Result, error) {
+	addr, err := v.a.messageProfile.parseAddress(addrHex)
+	if err != nil {
+		return nil, err
+	}
+
+	delegation, err := v.a.checkDelegateRegistries(challenge, common.FromHex(signature), addr)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Address: v.a.messageProfile.formatAddress(addr)}
+	if delegation != nil {
+		result.Authorized, result.Method, result.VaultDelegation = true, MethodDelegateRegistry, delegation
+	}
+	return result, nil
+}
+
+// check returns the widest level of delegation from vault to delegate within the scope, or an empty type if there is none.
+// Registries also match wider delegations on narrower checks, so the levels are checked from wallet-wide down.
+func (r *delegateRegistry) check(opts *bind.CallOpts, cc bind.ContractCaller, delegate, vault common.Address) (DelegationType, error) {
+	var checkAll func() (bool, error)
+	var checkContract func() (bool, error)
+	var checkToken func() (bool, error)
+
+	switch r.version {
+	case DelegateRegistryV1:
+		registry, err := ERCs.NewDelegateRegistryV1Caller(r.address, cc)
+		if err != nil {
+			return "", err
+		}
+		checkAll = func() (bool, error) {
+			return registry.CheckDelegateForAll(opts, delegate, vault)
+		}
+		checkContract = func() (bool, error) {
+			return registry.CheckDelegateForContract(opts, delegate, vault, *r.scope.Contract)
+		}
+		checkToken = func() (bool, error) {
+			return registry.CheckDelegateForToken(opts, delegate, vault, *r.scope.Contract, r.scope.TokenID)
+		}
+	case DelegateRegistryV2:
+		registry, err := ERCs.NewDelegateRegistryV2Caller(r.address, cc)
+		if err != nil {
+			return "", err
+		}
+		checkAll = func() (bool, error) {
+			return registry.CheckDelegateForAll(opts, delegate, vault, r.scope.Rights)
+		}
+		checkContract = func() (bool, error) {
+			return registry.CheckDelegateForContract(opts, delegate, vault, *r.scope.Contract, r.scope.Rights)
+		}
+		checkToken = func() (bool, error) {
+			return registry.CheckDelegateForERC721(opts, delegate, vault, *r.scope.Contract, r.scope.TokenID, r.scope.Rights)
+		}
+	default:
+		return "", fmt.Errorf("unknown delegation registry version %d", r.version)
+	}
+
+	levels := []struct {
+		delegationType DelegationType
+		inScope        bool
+		check          func() (bool, error)
+	}{
+		{DelegationTypeAll, true, checkAll},
+		{DelegationTypeContract, r.scope.Contract != nil, checkContract},
+		{DelegationTypeToken, r.scope.Contract != nil && r.scope.TokenID != nil, checkToken},
+	}
+
+	for _, level := range levels {
+		if !level.inScope {
+			break
+		}
+		ok, err := level.check()
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return level.delegationType, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,110 @@
+package dappauth
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/dapperlabs/dappauth/dappauthtest"
+	"github.com/ethereum/go-ethereum/common"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestDelegateRegistry(t *testing.T) {
+
+	keyHot, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+	keyOther, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+	keyVault, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+
+	hot := ethCrypto.PubkeyToAddress(keyHot.PublicKey)
+	vault := ethCrypto.PubkeyToAddress(keyVault.PublicKey)
+	nft := common.HexToAddress("0x00000000000000000000000000000000000000ff")
+	otherNFT := common.HexToAddress("0x00000000000000000000000000000000000000fe")
+
+	tests := []struct {
+		title              string
+		version            DelegateRegistryVersion
+		delegation         mockDelegation
+		scope              DelegationScope
+		signingKey         *ecdsa.PrivateKey
+		expectedAuthorized bool
+		expectedType       DelegationType
+	}{
+		{"Wallet-wide v2 delegations should authorize the hot wallet", DelegateRegistryV2, mockDelegation{delegate: hot, vault: vault}, DelegationScope{}, keyHot, true, DelegationTypeAll},
+		{"Wallet-wide v1 delegations should authorize the hot wallet", DelegateRegistryV1, mockDelegation{delegate: hot, vault: vault}, DelegationScope{}, keyHot, true, DelegationTypeAll},
+		{"Contract delegations should authorize the hot wallet for the contract", DelegateRegistryV2, mockDelegation{delegate: hot, vault: vault, contract: &nft}, DelegationScope{Contract: &nft}, keyHot, true, DelegationTypeContract},
+		{"Contract delegations should NOT authorize the hot wallet for other contracts", DelegateRegistryV2, mockDelegation{delegate: hot, vault: vault, contract: &nft}, DelegationScope{Contract: &otherNFT}, keyHot, false, ""},
+		{"Contract delegations should NOT authorize the hot wallet without a contract scope", DelegateRegistryV2, mockDelegation{delegate: hot, vault: vault, contract: &nft}, DelegationScope{}, keyHot, false, ""},
+		{"Token delegations should authorize the hot wallet for the token", DelegateRegistryV1, mockDelegation{delegate: hot, vault: vault, contract: &nft, tokenID: big.NewInt(7)}, DelegationScope{Contract: &nft, TokenID: big.NewInt(7)}, keyHot, true, DelegationTypeToken},
+		{"Token delegations should NOT authorize the hot wallet for other tokens", DelegateRegistryV2, mockDelegation{delegate: hot, vault: vault, contract: &nft, tokenID: big.NewInt(7)}, DelegationScope{Contract: &nft, TokenID: big.NewInt(8)}, keyHot, false, ""},
+		{"Delegations should NOT authorize other wallets", DelegateRegistryV2, mockDelegation{delegate: hot, vault: vault}, DelegationScope{}, keyOther, false, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.title, func(t *testing.T) {
+			registry := common.HexToAddress("0x00000000000000000000000000000000000000d1")
+			mock := &mockDelegateRegistry{
+				address:     registry,
+				version:     test.version,
+				delegations: []mockDelegation{test.delegation},
+				fallback:    dappauthtest.NewWallet(vault, 1),
+			}
+			authenticator := NewAuthenticator(nil, mock, WithDelegateRegistry(registry, test.version, test.scope))
+
+			result, err := authenticator.Verify("foo", signEOAPersonalMessage("foo", test.signingKey, t), vault.Hex())
+			checkError(err, t)
+
+			expectBool(result.Authorized, test.expectedAuthorized, t)
+			expectBool(result.VaultDelegation != nil, test.expectedAuthorized, t)
+			if result.VaultDelegation != nil {
+				expectString(string(result.Method), string(MethodDelegateRegistry), t)
+				expectString(string(result.VaultDelegation.Type), string(test.expectedType), t)
+				expectString(result.VaultDelegation.Delegate.Hex(), hot.Hex(), t)
+				expectString(result.VaultDelegation.Vault.Hex(), vault.Hex(), t)
+			}
+		})
+	}
+
+	t.Run("The vault's own signature should not need a delegation", func(t *testing.T) {
+		registry := common.HexToAddress("0x00000000000000000000000000000000000000d1")
+		authenticator := NewAuthenticator(nil, dappauthtest.NewWallet(vault, 1), WithDelegateRegistry(registry, DelegateRegistryV2, DelegationScope{}))
+
+		result, err := authenticator.Verify("foo", signEOAPersonalMessage("foo", keyVault, t), vault.Hex())
+		checkError(err, t)
+		expectString(string(result.Method), string(MethodEOA), t)
+	})
+
+	t.Run("Registry errors should fall through to the contract wallet", func(t *testing.T) {
+		registry := common.HexToAddress("0x00000000000000000000000000000000000000d1")
+		mock := &mockDelegateRegistry{
+			address:    registry,
+			version:    DelegateRegistryV2,
+			errorCheck: true,
+			fallback:   dappauthtest.NewWallet(vault, 1, hot),
+		}
+		authenticator := NewAuthenticator(nil, mock, WithDelegateRegistry(registry, DelegateRegistryV2, DelegationScope{}))
+
+		result, err := authenticator.Verify("foo", signERC1654PersonalMessage("foo", keyHot, vault, t), vault.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+		expectString(string(result.Method), string(MethodERC1271), t)
+	})
+
+	t.Run("Registry errors should be returned if the contract wallet flow fails as well", func(t *testing.T) {
+		registry := common.HexToAddress("0x00000000000000000000000000000000000000d1")
+		mock := &mockDelegateRegistry{
+			address:    registry,
+			version:    DelegateRegistryV2,
+			errorCheck: true,
+			fallback:   dappauthtest.NewWallet(vault, 1),
+		}
+		authenticator := NewAuthenticator(nil, mock, WithDelegateRegistry(registry, DelegateRegistryV2, DelegationScope{}))
+
+		_, err := authenticator.Verify("foo", signEOAPersonalMessage("foo", keyHot, t), vault.Hex())
+		expectBool(err != nil && strings.Contains(err.Error(), "Dummy error"), true, t)
+	})
+}
@@ -0,0 +1,75 @@
+package dappauth
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	_EIP7702DelegationPrefix = []byte{239, 1, 0} // 0xef0100
+)
+
+// DelegationPolicy decides how EIP-7702 delegated EOAs are verified, as they can validate signatures both by key and via ERC-1271.
+type DelegationPolicy int
+
+const (
+	// DelegationPolicyNone disables EIP-7702 detection (default), all addresses are checked by key first and then via ERC-1271.
+	DelegationPolicyNone DelegationPolicy = iota
+	// DelegationPolicyECDSAFirst checks delegated EOAs by key first and then via ERC-1271.
+	DelegationPolicyECDSAFirst
+	// DelegationPolicyERC1271First checks delegated EOAs via ERC-1271 first and then by key.
+	DelegationPolicyERC1271First
+	// DelegationPolicyECDSAOnly checks delegated EOAs by key only.
+	DelegationPolicyECDSAOnly
+	// DelegationPolicyERC1271Only checks delegated EOAs via ERC-1271 only, leaving signature validation to the delegate.
+	DelegationPolicyERC1271Only
+)
+
+// WithDelegationPolicy enables EIP-7702 delegated EOA detection, which costs an extra CodeAt call per verification.
+func WithDelegationPolicy(policy DelegationPolicy) Option {
+	return func(a *Authenticator) {
+		a.delegationPolicy = policy
+	}
+}
+
+// delegateOf returns the EIP-7702 delegate of the address, or nil if it isn't a delegated EOA.
+func (a *Authenticator) delegateOf(addr common.Address) (*common.Address, error) {
+	code, err := a.cc.CodeAt(a.ctx, addr, nil)
+	if err != nil {
+		return nil, fmt.Errorf("EIP-7702 delegation check errored with: '%v'", err)
+	}
+	return parseDelegation(code), nil
+}
+
+// delegatedStrategy is the flow of a delegated EOA for the policies that don't follow the default key first order.
+func (a *Authenticator) delegatedStrategy() *Strategy {
+	switch a.delegationPolicy {
+	case DelegationPolicyECDSAOnly:
+		return NewStrategy(StrategyAny, &EOAVerifier{a: a})
+
+	case DelegationPolicyERC1271Only:
+		return NewStrategy(StrategyAny, &ERC1271Verifier{a: a})
+
+	default:
+		strategy := NewStrategy(StrategyAny, &ERC1271Verifier{a: a}, &EOAVerifier{a: a})
+		strategy.mergeErrors = func(errs []error) error {
+			errCA, errEOA := errs[0], errs[1]
+			if errCA != nil {
+				return mergeErrors(errEOA, errCA)
+			}
+			return nil
+		}
+		return strategy
+	}
+}
+
+// parseDelegation returns the delegate of an EIP-7702 delegation designator (0xef0100 ++ address), or nil if the code isn't one.
+func parseDelegation(code []byte) *common.Address {
+	if len(code) != len(_EIP7702DelegationPrefix)+common.AddressLength || !bytes.HasPrefix(code, _EIP7702DelegationPrefix) {
+		return nil
+	}
+	delegate := common.BytesToAddress(code[len(_EIP7702DelegationPrefix):])
+	return &delegate
+}
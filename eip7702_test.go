@@ -0,0 +1,91 @@
+package dappauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"testing"
+	"time"
+
+	"github.com/dapperlabs/dappauth/dappauthtest"
+	"github.com/ethereum/go-ethereum/common"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestDelegatedEOA(t *testing.T) {
+
+	keyA, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+	keyB, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+
+	addrA := ethCrypto.PubkeyToAddress(keyA.PublicKey)
+	delegate := common.HexToAddress("0x00000000000000000000000000000000000000dd")
+	designator := append(common.FromHex("0xef0100"), delegate.Bytes()...)
+
+	// the delegate of addrA approves signatures of keyB
+	newMock := func(code []byte) *dappauthtest.Wallet {
+		wallet := dappauthtest.NewWallet(addrA, 1, ethCrypto.PubkeyToAddress(keyB.PublicKey))
+		wallet.Code = code
+		return wallet
+	}
+
+	tests := []struct {
+		title              string
+		policy             DelegationPolicy
+		code               []byte
+		signingKey         *ecdsa.PrivateKey
+		isEOA              bool
+		expectedAuthorized bool
+		expectedMethod     Method
+		expectedDelegate   *common.Address
+	}{
+		{"Detection disabled should not look up the code", DelegationPolicyNone, nil, keyA, true, true, MethodEOA, nil},
+		{"ECDSA first should authorize the key of a delegated EOA", DelegationPolicyECDSAFirst, designator, keyA, true, true, MethodEOA, &delegate},
+		{"ECDSA first should fall back to the delegate of a delegated EOA", DelegationPolicyECDSAFirst, designator, keyB, false, true, MethodERC1271, &delegate},
+		{"ERC1271 first should authorize via the delegate of a delegated EOA", DelegationPolicyERC1271First, designator, keyB, false, true, MethodERC1271, &delegate},
+		{"ERC1271 first should fall back to the key of a delegated EOA", DelegationPolicyERC1271First, designator, keyA, true, true, MethodEOA, &delegate},
+		{"ECDSA only should NOT authorize via the delegate of a delegated EOA", DelegationPolicyECDSAOnly, designator, keyB, false, false, "", &delegate},
+		{"ERC1271 only should NOT authorize the key of a delegated EOA", DelegationPolicyERC1271Only, designator, keyA, true, false, "", &delegate},
+		{"ERC1271 only should authorize via the delegate of a delegated EOA", DelegationPolicyERC1271Only, designator, keyB, false, true, MethodERC1271, &delegate},
+		{"Policies should not apply to regular EOAs", DelegationPolicyERC1271Only, []byte{}, keyA, true, true, MethodEOA, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.title, func(t *testing.T) {
+			authenticator := NewAuthenticator(nil, newMock(test.code), WithDelegationPolicy(test.policy))
+
+			sig := generateSignature(test.isEOA, "foo", test.signingKey, addrA, t)
+			result, err := authenticator.Verify("foo", sig, addrA.Hex())
+			checkError(err, t)
+
+			expectBool(result.Authorized, test.expectedAuthorized, t)
+			expectString(string(result.Method), string(test.expectedMethod), t)
+			expectBool(result.DelegatedTo != nil, test.expectedDelegate != nil, t)
+			if result.DelegatedTo != nil && test.expectedDelegate != nil {
+				expectString(result.DelegatedTo.Hex(), test.expectedDelegate.Hex(), t)
+			}
+		})
+	}
+
+	t.Run("Verification should error when the code lookup errors", func(t *testing.T) {
+		// the lookup of a slow wallet errors with the cancelled context
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		wallet := newMock(nil)
+		wallet.Delay = time.Second
+		authenticator := NewAuthenticator(ctx, wallet, WithDelegationPolicy(DelegationPolicyECDSAFirst))
+
+		_, err := authenticator.Verify("foo", generateSignature(true, "foo", keyA, addrA, t), addrA.Hex())
+		expectBool(err != nil, true, t)
+	})
+}
+
+func TestParseDelegation(t *testing.T) {
+	delegate := parseDelegation(common.FromHex("0xef010000000000000000000000000000000000000000dd"))
+	expectBool(delegate != nil, true, t)
+	expectString(delegate.Hex(), "0x00000000000000000000000000000000000000dd", t)
+
+	expectBool(parseDelegation(common.FromHex("0xef0100")) == nil, true, t)
+	expectBool(parseDelegation(common.FromHex("0x6080604052")) == nil, true, t)
+	expectBool(parseDelegation(common.FromHex("0xef010100000000000000000000000000000000000000dd")) == nil, true, t)
+}
@@ -0,0 +1,223 @@
+package dappauth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/adraffy/go-ens-normalize/ensip15"
+	"github.com/dapperlabs/dappauth/ERCs"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	// ENSRegistryAddress is the address of the ENS registry on mainnet and its main testnets.
+	ENSRegistryAddress = common.HexToAddress("0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e")
+)
+
+// ENSResolver resolves ENS names through the ENS registry and resolver contracts.
+type ENSResolver struct {
+	cc       bind.ContractCaller
+	ctx      context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+	registry common.Address
+}
+
+// NewENSResolver creates a new ENSResolver for the ENS registry at registry (usually ENSRegistryAddress).
+func NewENSResolver(ctx context.Context, cc bind.ContractCaller, registry common.Address) *ENSResolver {
+	// go-ethereum's clients don't accept a nil context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &ENSResolver{
+		ctx:      ctx,
+		cc:       cc,
+		registry: registry,
+	}
+}
+
+// WithENS allows ENS names as the address argument, resolving them via the ENS registry at registry.
+// If verifyReverse is set, the address must also reverse resolve to the name.
+func WithENS(registry common.Address, verifyReverse bool) Option {
+	return func(a *Authenticator) {
+		a.ens = NewENSResolver(a.ctx, a.cc, registry)
+		a.ensVerifyReverse = verifyReverse
+	}
+}
+
+// NormalizeENSName normalizes an ENS name following ENSIP-15, so that equivalent names have the same namehash.
+// Names that ENSIP-15 rejects, like confusables, disallowed characters or "xn--" labels, return an error.
+func NormalizeENSName(name string) (string, error) {
+	normalized, err := ensip15.Shared().Normalize(name)
+	if err != nil {
+		return "", fmt.Errorf("invalid ENS name %q: %v", name, err)
+	}
+	return normalized, nil
+}
+
+// ENSProfile is the primary ENS name of an address and its avatar.
+type ENSProfile struct {
+	Name   string // The primary name, verified to forward resolve to the address
+	Avatar string // The avatar text record of Name (empty if not set)
+}
+
+// WithENSProfile looks up the primary ENS name and avatar of authorized addresses, via the ENS registry at registry.
+// A failed lookup doesn't fail the verification, it is reported in Result.ENSProfileErr instead.
+func WithENSProfile(registry common.Address) Option {
+	return func(a *Authenticator) {
+		a.ensProfile = NewENSResolver(a.ctx, a.cc, registry)
+	}
+}
+
+// Resolve returns the normalized name and the address it resolves to.
+func (r *ENSResolver) Resolve(name string) (string, common.Address, error) {
+	normalized, resolver, err := r.lookup(name)
+	if err != nil {
+		return "", common.Address{}, err
+	}
+	if resolver == nil {
+		return "", common.Address{}, fmt.Errorf("ENS name %q has no resolver", normalized)
+	}
+
+	addr, err := resolver.Addr(namehash(normalized))
+	if err != nil {
+		return "", common.Address{}, fmt.Errorf("ENS addr call for %q errored with: '%v'", normalized, err)
+	}
+	if addr == (common.Address{}) {
+		return "", common.Address{}, fmt.Errorf("ENS name %q does not resolve to an address", normalized)
+	}
+
+	return normalized, addr, nil
+}
+
+// ReverseResolve returns the name the address reverse resolves to, or an empty string if it has none.
+// The name is not checked to forward resolve to the address, see Profile for that.
+func (r *ENSResolver) ReverseResolve(addr common.Address) (string, error) {
+	reverseName := strings.ToLower(strings.TrimPrefix(addr.Hex(), "0x")) + ".addr.reverse"
+
+	_, resolver, err := r.lookup(reverseName)
+	if err != nil || resolver == nil {
+		return "", err
+	}
+
+	name, err := resolver.Name(namehash(reverseName))
+	if err != nil {
+		return "", fmt.Errorf("ENS name call for %q errored with: '%v'", reverseName, err)
+	}
+	return name, nil
+}
+
+// Text returns the text record for key of the name, or an empty string if it isn't set.
+func (r *ENSResolver) Text(name, key string) (string, error) {
+	normalized, resolver, err := r.lookup(name)
+	if err != nil || resolver == nil {
+		return "", err
+	}
+
+	text, err := resolver.Text(namehash(normalized), key)
+	if err != nil {
+		return "", fmt.Errorf("ENS text call for %q errored with: '%v'", normalized, err)
+	}
+	return text, nil
+}
+
+// Profile returns the primary name of the address and its avatar, or nil if the address has no primary name.
+// The primary name must forward resolve back to the address.
+func (r *ENSResolver) Profile(addr common.Address) (*ENSProfile, error) {
+	reverseName, err := r.ReverseResolve(addr)
+	if err != nil || reverseName == "" {
+		return nil, err
+	}
+
+	name, resolver, err := r.lookup(reverseName)
+	if err != nil || resolver == nil {
+		// an invalid or unresolvable reverse record is no primary name
+		return nil, nil
+	}
+	resolved, err := resolver.Addr(namehash(name))
+	if err != nil {
+		return nil, fmt.Errorf("ENS addr call for %q errored with: '%v'", name, err)
+	}
+	if resolved != addr {
+		return nil, nil
+	}
+
+	avatar, err := resolver.Text(namehash(name), "avatar")
+	if err != nil {
+		return nil, fmt.Errorf("ENS text call for %q errored with: '%v'", name, err)
+	}
+
+	return &ENSProfile{Name: name, Avatar: avatar}, nil
+}
+
+// lookup normalizes the name and returns the resolver set for it in the registry (nil if it has none).
+func (r *ENSResolver) lookup(name string) (string, *ERCs.ENSResolverCallerSession, error) {
+	normalized, err := NormalizeENSName(name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	callOpts := bind.CallOpts{
+		Pending: false,
+		Context: r.ctx,
+	}
+
+	registry, err := ERCs.NewENSRegistryCaller(r.registry, r.cc)
+	if err != nil {
+		return "", nil, err
+	}
+	resolverAddr, err := registry.Resolver(&callOpts, namehash(normalized))
+	if err != nil {
+		return "", nil, fmt.Errorf("ENS resolver call for %q errored with: '%v'", normalized, err)
+	}
+	if resolverAddr == (common.Address{}) {
+		return normalized, nil, nil
+	}
+
+	resolver, err := ERCs.NewENSResolverCaller(resolverAddr, r.cc)
+	if err != nil {
+		return "", nil, err
+	}
+	return normalized, &ERCs.ENSResolverCallerSession{Contract: resolver, CallOpts: callOpts}, nil
+}
+
+// resolveSubject returns the address to verify, resolving ENS names if enabled.
+// The returned name is empty if addrHex is not an ENS name.
+func (a *Authenticator) resolveSubject(addrHex string) (common.Address, string, error) {
+	if a.ens == nil || common.IsHexAddress(addrHex) || !strings.Contains(addrHex, ".") {
+		addr, err := a.messageProfile.parseAddress(addrHex)
+		return addr, "", err
+	}
+
+	name, addr, err := a.ens.Resolve(addrHex)
+	if err != nil {
+		return common.Address{}, "", err
+	}
+
+	if a.ensVerifyReverse {
+		reverseName, err := a.ens.ReverseResolve(addr)
+		if err != nil {
+			return common.Address{}, "", err
+		}
+		if normalized, err := NormalizeENSName(reverseName); err != nil || normalized != name {
+			return common.Address{}, "", fmt.Errorf("ENS name %q does not match the reverse record of %s", name, addr.Hex())
+		}
+	}
+
+	return addr, name, nil
+}
+
+// namehash implements the ENS namehash algorithm over a normalized name.
+func namehash(name string) [32]byte {
+	var node [32]byte
+	if name == "" {
+		return node
+	}
+
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		copy(node[:], ethCrypto.Keccak256(node[:], ethCrypto.Keccak256([]byte(labels[i]))))
+	}
+	return node
+}
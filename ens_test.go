@@ -0,0 +1,222 @@
+package dappauth
+
+import (
+	"compress/gzip"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dapperlabs/dappauth/dappauthtest"
+	"github.com/ethereum/go-ethereum/common"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestENSSubject(t *testing.T) {
+
+	keyA, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+	keyB, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+
+	addrA := ethCrypto.PubkeyToAddress(keyA.PublicKey)
+	addrB := ethCrypto.PubkeyToAddress(keyB.PublicKey)
+
+	mock := &mockENS{
+		addrs:    map[string]common.Address{"alice.eth": addrA, "bob.eth": addrB},
+		reverse:  map[common.Address]string{addrA: "alice.eth", addrB: "notbob.eth"},
+		fallback: dappauthtest.NewWallet(addrB, 1),
+	}
+
+	t.Run("ENS names should be resolved when enabled", func(t *testing.T) {
+		authenticator := NewAuthenticator(nil, mock, WithENS(ENSRegistryAddress, false))
+
+		result, err := authenticator.Verify("foo", signEOAPersonalMessage("foo", keyA, t), "Alice.ETH")
+		checkError(err, t)
+
+		expectBool(result.Authorized, true, t)
+		expectString(result.Address, addrA.Hex(), t)
+		expectString(result.ENSName, "alice.eth", t)
+	})
+
+	t.Run("ENS names should NOT authorize signatures of other addresses", func(t *testing.T) {
+		authenticator := NewAuthenticator(nil, mock, WithENS(ENSRegistryAddress, false))
+
+		result, err := authenticator.Verify("foo", signEOAPersonalMessage("foo", keyA, t), "bob.eth")
+		checkError(err, t)
+		expectBool(result.Authorized, false, t)
+	})
+
+	t.Run("Hex addresses should not be resolved", func(t *testing.T) {
+		authenticator := NewAuthenticator(nil, mock, WithENS(ENSRegistryAddress, true))
+
+		result, err := authenticator.Verify("foo", signEOAPersonalMessage("foo", keyA, t), addrA.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+		expectString(result.ENSName, "", t)
+	})
+
+	t.Run("Reverse resolution should be verified when enabled", func(t *testing.T) {
+		authenticator := NewAuthenticator(nil, mock, WithENS(ENSRegistryAddress, true))
+
+		result, err := authenticator.Verify("foo", signEOAPersonalMessage("foo", keyA, t), "alice.eth")
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+
+		_, err = authenticator.Verify("foo", signEOAPersonalMessage("foo", keyB, t), "bob.eth")
+		expectBool(err != nil, true, t)
+	})
+
+	t.Run("Unknown ENS names should error", func(t *testing.T) {
+		authenticator := NewAuthenticator(nil, mock, WithENS(ENSRegistryAddress, false))
+
+		_, err := authenticator.Verify("foo", signEOAPersonalMessage("foo", keyA, t), "carol.eth")
+		expectBool(err != nil, true, t)
+	})
+}
+
+func TestNamehash(t *testing.T) {
+	node := namehash("")
+	expectString(hex.EncodeToString(node[:]), "0000000000000000000000000000000000000000000000000000000000000000", t)
+	node = namehash("eth")
+	expectString(hex.EncodeToString(node[:]), "93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae", t)
+	node = namehash("foo.eth")
+	expectString(hex.EncodeToString(node[:]), "de9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f", t)
+}
+
+func TestNormalizeENSName(t *testing.T) {
+	name, err := NormalizeENSName("Foo.ETH")
+	checkError(err, t)
+	expectString(name, "foo.eth", t)
+
+	name, err = NormalizeENSName("ｆｏｏ.eth")
+	checkError(err, t)
+	expectString(name, "foo.eth", t)
+
+	_, err = NormalizeENSName("foo..eth")
+	expectBool(err != nil, true, t)
+
+	_, err = NormalizeENSName("foo bar.eth")
+	expectBool(err != nil, true, t)
+
+	// ASCII labels with "--" at the 3rd and 4th characters, like punycode, are invalid
+	_, err = NormalizeENSName("xn--ls8h.eth")
+	expectBool(err != nil, true, t)
+
+	_, err = NormalizeENSName("ＸＮ--ls8h.eth")
+	expectBool(err != nil, true, t)
+
+	name, err = NormalizeENSName("a--b.eth")
+	checkError(err, t)
+	expectString(name, "a--b.eth", t)
+
+	// underscores are only allowed at the start of a label
+	name, err = NormalizeENSName("_A.eth")
+	checkError(err, t)
+	expectString(name, "_a.eth", t)
+
+	_, err = NormalizeENSName("A_b.eth")
+	expectBool(err != nil, true, t)
+
+	name, err = NormalizeENSName("💩.eth")
+	checkError(err, t)
+	expectString(name, "💩.eth", t)
+
+	// emoji sequences are kept whole, without FE0F
+	name, err = NormalizeENSName("👨‍👩‍👧.eth")
+	checkError(err, t)
+	expectString(name, "👨‍👩‍👧.eth", t)
+
+	name, err = NormalizeENSName("🏳️‍🌈.eth")
+	checkError(err, t)
+	expectString(name, "🏳‍🌈.eth", t)
+
+	// whole script confusables, like the Cyrillic "аррӏе"
+	_, err = NormalizeENSName("аррӏе.eth")
+	expectBool(err != nil, true, t)
+
+	// mixed scripts
+	_, err = NormalizeENSName("aα.eth")
+	expectBool(err != nil, true, t)
+}
+
+// TestNormalizeENSNameVectors runs the ENSIP-15 validation tests of the reference implementation (testdata/ensip15_tests.json.gz).
+func TestNormalizeENSNameVectors(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "ensip15_tests.json.gz"))
+	checkError(err, t)
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	checkError(err, t)
+
+	var vectors []struct {
+		Name  string  `json:"name"`
+		Norm  *string `json:"norm"`
+		Error bool    `json:"error"`
+	}
+	checkError(json.NewDecoder(reader).Decode(&vectors), t)
+
+	// the first entry is the version of the tests
+	for _, vector := range vectors[1:] {
+		normalized, err := NormalizeENSName(vector.Name)
+		switch {
+		case vector.Error && err == nil:
+			t.Errorf("%+q: expected an error, got %+q", vector.Name, normalized)
+		case !vector.Error && err != nil:
+			t.Errorf("%+q: %v", vector.Name, err)
+		case !vector.Error && vector.Norm != nil && normalized != *vector.Norm:
+			t.Errorf("%+q: expected %+q, got %+q", vector.Name, *vector.Norm, normalized)
+		case !vector.Error && vector.Norm == nil && normalized != vector.Name:
+			t.Errorf("%+q: expected it unchanged, got %+q", vector.Name, normalized)
+		}
+	}
+}
+
+func TestENSProfile(t *testing.T) {
+
+	keyA, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+	keyB, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+	keyC, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+
+	addrA := ethCrypto.PubkeyToAddress(keyA.PublicKey)
+	addrB := ethCrypto.PubkeyToAddress(keyB.PublicKey)
+	addrC := ethCrypto.PubkeyToAddress(keyC.PublicKey)
+
+	mock := &mockENS{
+		addrs:   map[string]common.Address{"alice.eth": addrA, "bob.eth": addrA},
+		reverse: map[common.Address]string{addrA: "Alice.eth", addrB: "bob.eth"},
+		texts:   map[string]map[string]string{"alice.eth": {"avatar": "https://example.com/alice.png"}},
+	}
+	authenticator := NewAuthenticator(nil, mock, WithENSProfile(ENSRegistryAddress))
+
+	t.Run("Authorized addresses should have their primary name and avatar attached", func(t *testing.T) {
+		result, err := authenticator.Verify("foo", signEOAPersonalMessage("foo", keyA, t), addrA.Hex())
+		checkError(err, t)
+
+		expectBool(result.Authorized, true, t)
+		checkError(result.ENSProfileErr, t)
+		expectBool(result.ENSProfile != nil, true, t)
+		expectString(result.ENSProfile.Name, "alice.eth", t)
+		expectString(result.ENSProfile.Avatar, "https://example.com/alice.png", t)
+	})
+
+	t.Run("Primary names that don't forward resolve back should be ignored", func(t *testing.T) {
+		result, err := authenticator.Verify("foo", signEOAPersonalMessage("foo", keyB, t), addrB.Hex())
+		checkError(err, t)
+
+		expectBool(result.Authorized, true, t)
+		expectBool(result.ENSProfile == nil, true, t)
+	})
+
+	t.Run("Addresses without a primary name should have no profile", func(t *testing.T) {
+		result, err := authenticator.Verify("foo", signEOAPersonalMessage("foo", keyC, t), addrC.Hex())
+		checkError(err, t)
+
+		expectBool(result.Authorized, true, t)
+		expectBool(result.ENSProfile == nil, true, t)
+		checkError(result.ENSProfileErr, t)
+	})
+}
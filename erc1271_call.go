@@ -0,0 +1,70 @@
+package dappauth
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/dapperlabs/dappauth/ERCs"
+	"github.com/ethereum/go-ethereum"
+	ethAbi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// ContractCallConfig sets the call parameters of ERC-1271 isValidSignature calls.
+type ContractCallConfig struct {
+	From     common.Address // msg.sender of the call (zero = the node's default)
+	Gas      uint64         // Gas ceiling of the call, running out of it means not authorized (0 = the node's cap)
+	GasPrice *big.Int       // (nil = the node's default)
+}
+
+// WithContractCall sets the call parameters of ERC-1271 isValidSignature calls.
+func WithContractCall(config ContractCallConfig) Option {
+	return func(a *Authenticator) {
+		a.contractCall = config
+	}
+}
+
+// isValidSignature calls ERC-1271 isValidSignature of the contract with the configured call parameters, which bind.CallOpts can't set.
+// It returns the shape of the return data that held the magic value, or an empty shape if it didn't.
+func (a *Authenticator) isValidSignature(addr common.Address, hash [32]byte, origSigBytes []byte) (ReturnShape, error) {
+	abi, err := ethAbi.JSON(strings.NewReader(ERCs.ERC1271ABI))
+	if err != nil {
+		return "", err
+	}
+	input, err := abi.Pack("isValidSignature", hash, origSigBytes)
+	if err != nil {
+		return "", err
+	}
+
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	output, err := a.cc.CallContract(ctx, ethereum.CallMsg{
+		From:     a.contractCall.From,
+		To:       &addr,
+		Gas:      a.contractCall.Gas,
+		GasPrice: a.contractCall.GasPrice,
+		Data:     input,
+	}, nil)
+	if err != nil {
+		a.explain(ExplainStep{Type: ExplainStepCall, Address: &addr, Calldata: input, Err: err.Error()})
+		return "", err
+	}
+
+	returnShape, err := matchMagicValue(abi, output, _ERC1271MagicValue, a.returnDecoding)
+	a.explain(ExplainStep{Type: ExplainStepCall, Address: &addr, Calldata: input, ReturnData: output, ReturnShape: returnShape, Err: errString(err)})
+	return returnShape, err
+}
+
+// isOutOfGas reports whether the call error means it ran out of gas, as reported by eth_call or a Simulator .
+func isOutOfGas(err error) bool {
+	if errors.Is(err, vm.ErrOutOfGas) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, vm.ErrOutOfGas.Error()) || strings.Contains(msg, "gas required exceeds allowance")
+}
@@ -0,0 +1,62 @@
+package dappauth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// recordingCaller records the calls it forwards.
+type recordingCaller struct {
+	bind.ContractCaller
+	calls []ethereum.CallMsg
+}
+
+func (r *recordingCaller) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	r.calls = append(r.calls, call)
+	return r.ContractCaller.CallContract(ctx, call, blockNumber)
+}
+
+func TestContractCall(t *testing.T) {
+
+	wallet := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	newSimulator := func() *Simulator {
+		return NewSimulator(nil, &mockStateReader{
+			header:  &types.Header{Number: big.NewInt(12244000), GasLimit: 15000000, Difficulty: new(big.Int)},
+			code:    map[common.Address][]byte{wallet: common.FromHex(approvedHashWalletCode)},
+			storage: map[common.Address]map[common.Hash]common.Hash{wallet: {{}: scMessageHash("foo")}},
+		}, SimulatorConfig{})
+	}
+
+	t.Run("Calls should use the configured call parameters", func(t *testing.T) {
+		caller := &recordingCaller{ContractCaller: newSimulator()}
+		config := ContractCallConfig{
+			From:     common.HexToAddress("0x00000000000000000000000000000000000000bb"),
+			Gas:      100000,
+			GasPrice: big.NewInt(1),
+		}
+		authenticator := NewAuthenticator(nil, caller, WithContractCall(config))
+
+		isAuthorizedSigner, err := authenticator.IsAuthorizedSigner("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, true, t)
+
+		expectBool(len(caller.calls) == 1, true, t)
+		expectString(caller.calls[0].From.Hex(), config.From.Hex(), t)
+		expectBool(caller.calls[0].Gas == config.Gas, true, t)
+		expectBool(caller.calls[0].GasPrice.Cmp(config.GasPrice) == 0, true, t)
+	})
+
+	t.Run("Running out of gas should NOT authorize, without an error", func(t *testing.T) {
+		authenticator := NewAuthenticator(nil, newSimulator(), WithContractCall(ContractCallConfig{Gas: 100}))
+
+		isAuthorizedSigner, err := authenticator.IsAuthorizedSigner("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, false, t)
+	})
+}
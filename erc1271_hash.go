@@ -0,0 +1,79 @@
+package dappauth
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// HashMode selects the hash of the challenge that is passed to ERC-1271 isValidSignature.
+type HashMode string
+
+const (
+	// HashModeRaw passes keccak256 of the challenge, which the contract hashes on top to an erc191 hash (default).
+	HashModeRaw HashMode = "raw"
+	// HashModePersonal passes the EIP-191 personal message hash, as expected by Safe, Argent, Coinbase Smart Wallet and ERC-4337 accounts.
+	HashModePersonal HashMode = "personal"
+	// HashModeTypedData passes the EIP-712 digest of a challenge that is EIP-712 typed data JSON.
+	HashModeTypedData HashMode = "typed_data"
+	// HashModeAuto tries HashModeRaw, HashModePersonal and HashModeTypedData in order, until one validates.
+	HashModeAuto HashMode = "auto"
+)
+
+// WithHashMode sets the hash mode of ERC-1271 calls.
+func WithHashMode(mode HashMode) Option {
+	return func(a *Authenticator) {
+		a.hashMode = mode
+	}
+}
+
+// hashModes returns the hash modes to try in order for mode.
+func (mode HashMode) hashModes() []HashMode {
+	switch mode {
+	case "":
+		return []HashMode{HashModeRaw}
+	case HashModeAuto:
+		return []HashMode{HashModeRaw, HashModePersonal, HashModeTypedData}
+	default:
+		return []HashMode{mode}
+	}
+}
+
+// erc1271Hash returns the hash of the challenge passed to isValidSignature in the hash mode.
+func erc1271Hash(challenge string, mode HashMode) ([32]byte, error) {
+	switch mode {
+	case HashModeRaw:
+		return scMessageHash(challenge), nil
+	case HashModePersonal:
+		var hash [32]byte
+		copy(hash[:], personalMessageHash(challenge))
+		return hash, nil
+	case HashModeTypedData:
+		return typedDataHash(challenge)
+	default:
+		return [32]byte{}, fmt.Errorf("unknown hash mode %q", mode)
+	}
+}
+
+// typedDataHash returns the EIP-712 digest of a challenge that is EIP-712 typed data JSON.
+func typedDataHash(challenge string) ([32]byte, error) {
+	var typedData apitypes.TypedData
+	if err := json.Unmarshal(decodeChallenge(challenge), &typedData); err != nil {
+		return [32]byte{}, fmt.Errorf("challenge is not EIP-712 typed data: %v", err)
+	}
+
+	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		return [32]byte{}, err
+	}
+	messageHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	var hash [32]byte
+	copy(hash[:], ethCrypto.Keccak256([]byte{25, 1}, domainSeparator, messageHash))
+	return hash, nil
+}
@@ -0,0 +1,117 @@
+package dappauth
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"testing"
+
+	"github.com/dapperlabs/dappauth/dappauthtest"
+	"github.com/ethereum/go-ethereum/common"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+const testTypedDataChallenge = `{
+	"types": {
+		"EIP712Domain": [{"name": "name", "type": "string"}, {"name": "chainId", "type": "uint256"}],
+		"Login": [{"name": "challenge", "type": "string"}]
+	},
+	"primaryType": "Login",
+	"domain": {"name": "dappauth", "chainId": "1"},
+	"message": {"challenge": "foo"}
+}`
+
+func TestHashMode(t *testing.T) {
+
+	keyA, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+	keyB, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+
+	addrA := ethCrypto.PubkeyToAddress(keyA.PublicKey)
+	mock := dappauthtest.NewWallet(addrA, 1, ethCrypto.PubkeyToAddress(keyB.PublicKey))
+
+	typedHash, err := typedDataHash(testTypedDataChallenge)
+	checkError(err, t)
+	personalHash := personalMessageHash("foo")
+	rawHash := scMessageHash("foo")
+
+	tests := []struct {
+		title              string
+		mode               HashMode
+		challenge          string
+		signedHash         []byte
+		expectedAuthorized bool
+		expectedMode       HashMode
+	}{
+		{"Default mode should pass the raw hash", "", "foo", rawHash[:], true, HashModeRaw},
+		{"Default mode should NOT pass the personal hash", "", "foo", personalHash, false, ""},
+		{"Personal mode should pass the personal hash", HashModePersonal, "foo", personalHash, true, HashModePersonal},
+		{"Personal mode should NOT pass the raw hash", HashModePersonal, "foo", rawHash[:], false, ""},
+		{"Typed data mode should pass the EIP-712 digest", HashModeTypedData, testTypedDataChallenge, typedHash[:], true, HashModeTypedData},
+		{"Auto mode should fall back to the personal hash", HashModeAuto, "foo", personalHash, true, HashModePersonal},
+		{"Auto mode should fall back to the EIP-712 digest", HashModeAuto, testTypedDataChallenge, typedHash[:], true, HashModeTypedData},
+	}
+
+	for _, test := range tests {
+		t.Run(test.title, func(t *testing.T) {
+			authenticator := NewAuthenticator(nil, mock, WithHashMode(test.mode))
+
+			sig := signERC1654Hash(test.signedHash, keyB, addrA, t)
+			result, err := authenticator.Verify(test.challenge, sig, addrA.Hex())
+			checkError(err, t)
+
+			expectBool(result.Authorized, test.expectedAuthorized, t)
+			expectString(string(result.HashMode), string(test.expectedMode), t)
+		})
+	}
+
+	t.Run("Typed data mode should error when the challenge is not typed data", func(t *testing.T) {
+		authenticator := NewAuthenticator(nil, mock, WithHashMode(HashModeTypedData))
+
+		_, err := authenticator.Verify("foo", signERC1654Hash(personalHash, keyB, addrA, t), addrA.Hex())
+		expectBool(err != nil, true, t)
+	})
+
+	t.Run("Auto mode should error only when every mode errored", func(t *testing.T) {
+		reverting := dappauthtest.NewWallet(addrA, 1, ethCrypto.PubkeyToAddress(keyB.PublicKey))
+		reverting.Revert = true
+		authenticator := NewAuthenticator(nil, reverting, WithHashMode(HashModeAuto))
+
+		_, err := authenticator.Verify("foo", signERC1654Hash(personalHash, keyB, addrA, t), addrA.Hex())
+		expectBool(err != nil, true, t)
+	})
+}
+
+func TestTypedDataHash(t *testing.T) {
+	// the example of the EIP-712 specification
+	hash, err := typedDataHash(`{
+		"types": {
+			"EIP712Domain": [
+				{"name": "name", "type": "string"},
+				{"name": "version", "type": "string"},
+				{"name": "chainId", "type": "uint256"},
+				{"name": "verifyingContract", "type": "address"}
+			],
+			"Person": [{"name": "name", "type": "string"}, {"name": "wallet", "type": "address"}],
+			"Mail": [{"name": "from", "type": "Person"}, {"name": "to", "type": "Person"}, {"name": "contents", "type": "string"}]
+		},
+		"primaryType": "Mail",
+		"domain": {"name": "Ether Mail", "version": "1", "chainId": "1", "verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"},
+		"message": {
+			"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+			"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+			"contents": "Hello, Bob!"
+		}
+	}`)
+	checkError(err, t)
+	expectString(hex.EncodeToString(hash[:]), "be609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2", t)
+}
+
+// emulates contract wallets that sign an erc191 hash over the hash passed to isValidSignature
+func signERC1654Hash(hash []byte, key *ecdsa.PrivateKey, address common.Address, t *testing.T) string {
+	sig, err := ethCrypto.Sign(erc191MessageHash(hash, address), key)
+	checkError(err, t)
+
+	sig[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
+	return hex.EncodeToString(sig)
+}
@@ -0,0 +1,99 @@
+package dappauth
+
+import (
+	"bytes"
+	"fmt"
+
+	ethAbi "github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// ReturnDecoding sets how strictly isValidSignature return data is decoded.
+type ReturnDecoding int
+
+const (
+	// ReturnDecodingStandard decodes the first word as bytes4 like the generated binding, ignoring padding and trailing data (default).
+	ReturnDecodingStandard ReturnDecoding = iota
+	// ReturnDecodingStrict only accepts a single word holding the zero padded bytes4.
+	ReturnDecodingStrict
+	// ReturnDecodingTolerant also accepts the shapes of non-compliant legacy wallets: a left-padded bytes32, a bool true, or an unpadded bytes4.
+	ReturnDecodingTolerant
+)
+
+// ReturnShape is the shape of the isValidSignature return data that matched the magic value.
+type ReturnShape string
+
+const (
+	// ReturnShapeBytes4 is a compliant bytes4, in a single word.
+	ReturnShapeBytes4 ReturnShape = "bytes4"
+	// ReturnShapeBytes4Trailing is a bytes4 word followed by extra data.
+	ReturnShapeBytes4Trailing ReturnShape = "bytes4_trailing"
+	// ReturnShapeBytes32 is the magic value left-padded to a bytes32.
+	ReturnShapeBytes32 ReturnShape = "bytes32"
+	// ReturnShapeBool is a bool true.
+	ReturnShapeBool ReturnShape = "bool"
+	// ReturnShapePacked is the 4 bytes of the magic value without padding.
+	ReturnShapePacked ReturnShape = "packed"
+)
+
+// WithReturnDecoding sets how strictly isValidSignature return data is decoded.
+func WithReturnDecoding(decoding ReturnDecoding) Option {
+	return func(a *Authenticator) {
+		a.returnDecoding = decoding
+	}
+}
+
+// matchMagicValue returns the shape of the return data that holds the magic value, or an empty shape if it doesn't.
+// It errors if the return data can't be decoded at all, like the generated binding does.
+func matchMagicValue(abi ethAbi.ABI, output []byte, magicValue [4]byte, decoding ReturnDecoding) (ReturnShape, error) {
+	switch decoding {
+	case ReturnDecodingStrict:
+		if len(output) != 32 {
+			return "", fmt.Errorf("isValidSignature returned %d bytes, expected a bytes4 word", len(output))
+		}
+		if bytes.Equal(output[:4], magicValue[:]) && isZero(output[4:]) {
+			return ReturnShapeBytes4, nil
+		}
+		return "", nil
+
+	case ReturnDecodingTolerant:
+		switch {
+		case len(output) == 4 && bytes.Equal(output, magicValue[:]):
+			return ReturnShapePacked, nil
+		case len(output) < 32:
+			break
+		case bytes.Equal(output[:4], magicValue[:]):
+			return bytes4Shape(output), nil
+		case isZero(output[:28]) && bytes.Equal(output[28:32], magicValue[:]):
+			return ReturnShapeBytes32, nil
+		case isZero(output[:31]) && output[31] == 1:
+			return ReturnShapeBool, nil
+		default:
+			return "", nil
+		}
+	}
+
+	unpacked, err := abi.Unpack("isValidSignature", output)
+	if err != nil {
+		return "", err
+	}
+	if unpacked[0].([4]byte) != magicValue {
+		return "", nil
+	}
+	return bytes4Shape(output), nil
+}
+
+func bytes4Shape(output []byte) ReturnShape {
+	if len(output) > 32 {
+		return ReturnShapeBytes4Trailing
+	}
+	return ReturnShapeBytes4
+}
+
+func isZero(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
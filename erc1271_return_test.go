@@ -0,0 +1,73 @@
+package dappauth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// returningCode returns code that returns the data.
+func returningCode(data []byte) []byte {
+	code := revertingCode(data)
+	code[11] = 0xf3 // RETURN instead of REVERT
+	return code
+}
+
+func TestReturnDecoding(t *testing.T) {
+
+	wallet := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	word := func(b []byte, leftPadded bool) []byte {
+		w := make([]byte, 32)
+		if leftPadded {
+			copy(w[32-len(b):], b)
+		} else {
+			copy(w, b)
+		}
+		return w
+	}
+	magic := _ERC1271MagicValue[:]
+
+	verify := func(output []byte, decoding ReturnDecoding) (*Result, error) {
+		simulator := NewSimulator(nil, &mockStateReader{
+			header: &types.Header{Number: big.NewInt(12244000), GasLimit: 15000000, Difficulty: new(big.Int)},
+			code:   map[common.Address][]byte{wallet: returningCode(output)},
+		}, SimulatorConfig{})
+		return NewAuthenticator(nil, simulator, WithReturnDecoding(decoding)).Verify("foo", dummySignature, wallet.Hex())
+	}
+
+	cases := []struct {
+		name     string
+		output   []byte
+		standard ReturnShape
+		strict   ReturnShape
+		tolerant ReturnShape
+	}{
+		{"compliant bytes4", word(magic, false), ReturnShapeBytes4, ReturnShapeBytes4, ReturnShapeBytes4},
+		{"bytes4 with trailing data", append(word(magic, false), word([]byte{1}, true)...), ReturnShapeBytes4Trailing, "", ReturnShapeBytes4Trailing},
+		{"left-padded bytes32", word(magic, true), "", "", ReturnShapeBytes32},
+		{"bool true", word([]byte{1}, true), "", "", ReturnShapeBool},
+		{"unpadded bytes4", magic, "", "", ReturnShapePacked},
+		{"other value", word([]byte{2}, true), "", "", ""},
+	}
+
+	for _, c := range cases {
+		t.Run("The "+c.name+" shape should be decoded by strictness", func(t *testing.T) {
+			for decoding, expected := range map[ReturnDecoding]ReturnShape{
+				ReturnDecodingStandard: c.standard,
+				ReturnDecodingStrict:   c.strict,
+				ReturnDecodingTolerant: c.tolerant,
+			} {
+				result, err := verify(c.output, decoding)
+				if err != nil {
+					// shapes the strictness can't decode at all error, like the generated binding
+					expectBool(expected == "", true, t)
+					continue
+				}
+				expectBool(result.Authorized, expected != "", t)
+				expectString(string(result.ReturnShape), string(expected), t)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package dappauth
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// ExplainStepType identifies a step of an explained verification.
+type ExplainStepType string
+
+const (
+	// ExplainStepEOA is the recovery of the key that signed the personal message hash.
+	ExplainStepEOA ExplainStepType = "eoa_recover"
+	// ExplainStepCode is the lookup of the code at the address, before the contract path.
+	ExplainStepCode ExplainStepType = "contract_code"
+	// ExplainStepHash is the computation of the hash passed to isValidSignature in a hash mode.
+	ExplainStepHash ExplainStepType = "erc1271_hash"
+	// ExplainStepCall is an isValidSignature call.
+	ExplainStepCall ExplainStepType = "erc1271_call"
+)
+
+// ExplainStep is a step of an explained verification. Only the fields of its type are set.
+type ExplainStep struct {
+	Type        ExplainStepType `json:"type"`
+	HashMode    HashMode        `json:"hashMode,omitempty"`
+	Hash        hexutil.Bytes   `json:"hash,omitempty"`
+	Address     *common.Address `json:"address,omitempty"`  // The recovered address (EOA) or the called contract
+	CodeSize    *int            `json:"codeSize,omitempty"` // (contract code only)
+	CodeHash    *common.Hash    `json:"codeHash,omitempty"` // (contract code only)
+	Calldata    hexutil.Bytes   `json:"calldata,omitempty"`
+	ReturnData  hexutil.Bytes   `json:"returnData,omitempty"`
+	ReturnShape ReturnShape     `json:"returnShape,omitempty"`
+	Err         string          `json:"error,omitempty"`
+}
+
+// Explanation records every step of a verification and its decision.
+type Explanation struct {
+	Challenge         string        `json:"challenge"`
+	ChallengeBytes    hexutil.Bytes `json:"challengeBytes"`
+	ChallengeEncoding string        `json:"challengeEncoding"` // "hex" if the challenge was hex decoded, "utf8" otherwise
+	Signature         hexutil.Bytes `json:"signature"`
+	Address           string        `json:"address"` // The address as given
+	Steps             []ExplainStep `json:"steps"`
+
+	Authorized  bool        `json:"authorized"`
+	Method      Method      `json:"method,omitempty"`
+	HashMode    HashMode    `json:"hashMode,omitempty"`
+	ReturnShape ReturnShape `json:"returnShape,omitempty"`
+	Err         string      `json:"error,omitempty"`
+
+	Result *Result `json:"-"` // The result of the verification (nil if it errored)
+}
+
+// JSON returns the explanation as indented JSON.
+func (e *Explanation) JSON() ([]byte, error) {
+	return json.MarshalIndent(e, "", "  ")
+}
+
+// Explain runs Verify and records every step of it. Errors of the verification are part of the explanation.
+func (a *Authenticator) Explain(challenge, signature, addrHex string) *Explanation {
+	explanation := &Explanation{
+		Challenge:         challenge,
+		ChallengeBytes:    decodeChallenge(challenge),
+		ChallengeEncoding: challengeEncoding(challenge),
+		Signature:         common.FromHex(signature),
+		Address:           addrHex,
+		Steps:             []ExplainStep{},
+	}
+
+	// the steps are recorded by a copy, so concurrent verifications of a aren't recorded
+	explained := *a
+	explained.explanation = explanation
+
+	result, err := explained.Verify(challenge, signature, addrHex)
+	if err != nil {
+		explanation.Err = err.Error()
+		return explanation
+	}
+	explanation.Result = result
+	explanation.Authorized, explanation.Method = result.Authorized, result.Method
+	explanation.HashMode, explanation.ReturnShape = result.HashMode, result.ReturnShape
+	return explanation
+}
+
+// explain records a step if the verification is explained.
+func (a *Authenticator) explain(step ExplainStep) {
+	if a.explanation != nil {
+		a.explanation.Steps = append(a.explanation.Steps, step)
+	}
+}
+
+// explainCode records the code at the address if the verification is explained.
+func (a *Authenticator) explainCode(addr common.Address) {
+	if a.explanation == nil {
+		return
+	}
+
+	step := ExplainStep{Type: ExplainStepCode, Address: &addr}
+	code, err := a.cc.CodeAt(a.ctx, addr, nil)
+	if err != nil {
+		step.Err = err.Error()
+	} else {
+		codeSize, codeHash := len(code), ethCrypto.Keccak256Hash(code)
+		step.CodeSize, step.CodeHash = &codeSize, &codeHash
+	}
+	a.explain(step)
+}
+
+// challengeEncoding returns how decodeChallenge decodes the challenge.
+func challengeEncoding(challenge string) string {
+	if _, err := hex.DecodeString(strings.TrimPrefix(challenge, "0x")); err == nil {
+		return "hex"
+	}
+	return "utf8"
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
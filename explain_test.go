@@ -0,0 +1,83 @@
+package dappauth
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestExplain(t *testing.T) {
+
+	wallet := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	simulator := NewSimulator(nil, &mockStateReader{
+		header:  &types.Header{Number: big.NewInt(12244000), GasLimit: 15000000, Difficulty: new(big.Int)},
+		code:    map[common.Address][]byte{wallet: common.FromHex(approvedHashWalletCode)},
+		storage: map[common.Address]map[common.Hash]common.Hash{wallet: {{}: scMessageHash("foo")}},
+	}, SimulatorConfig{})
+
+	t.Run("External wallet decisions should be explained", func(t *testing.T) {
+		key, err := ethCrypto.GenerateKey()
+		checkError(err, t)
+		address := ethCrypto.PubkeyToAddress(key.PublicKey)
+		authenticator := NewAuthenticator(nil, simulator)
+
+		explanation := authenticator.Explain("0xabcd", signEOAPersonalMessage("0xabcd", key, t), address.Hex())
+
+		expectBool(explanation.Authorized, true, t)
+		expectString(string(explanation.Method), string(MethodEOA), t)
+		expectString(explanation.ChallengeEncoding, "hex", t)
+		expectString(explanation.ChallengeBytes.String(), "0xabcd", t)
+		expectBool(len(explanation.Steps) == 1, true, t)
+		expectString(string(explanation.Steps[0].Type), string(ExplainStepEOA), t)
+		expectString(explanation.Steps[0].Address.Hex(), address.Hex(), t)
+	})
+
+	t.Run("Contract wallet decisions should be explained", func(t *testing.T) {
+		authenticator := NewAuthenticator(nil, simulator, WithHashMode(HashModeAuto))
+
+		explanation := authenticator.Explain("bar", dummySignature, wallet.Hex())
+
+		expectBool(explanation.Authorized, false, t)
+		expectString(explanation.ChallengeEncoding, "utf8", t)
+
+		var types []string
+		for _, step := range explanation.Steps {
+			types = append(types, string(step.Type))
+		}
+		// the challenge isn't typed data JSON, so it has no typed data hash to call with
+		expected := []ExplainStepType{ExplainStepEOA, ExplainStepCode, ExplainStepHash, ExplainStepCall, ExplainStepHash, ExplainStepCall, ExplainStepHash}
+		expectBool(len(types) == len(expected), true, t)
+		for i := range expected {
+			expectString(types[i], string(expected[i]), t)
+		}
+
+		code := explanation.Steps[1]
+		expectBool(*code.CodeSize == len(common.FromHex(approvedHashWalletCode)), true, t)
+		call := explanation.Steps[3]
+		expectBool(len(call.Calldata) > 4 && len(call.ReturnData) == 32, true, t)
+		expectBool(explanation.Steps[6].Err != "", true, t)
+	})
+
+	t.Run("Explanations should be JSON encodable", func(t *testing.T) {
+		authenticator := NewAuthenticator(nil, simulator)
+
+		b, err := authenticator.Explain("foo", dummySignature, wallet.Hex()).JSON()
+		checkError(err, t)
+
+		var decoded map[string]interface{}
+		checkError(json.Unmarshal(b, &decoded), t)
+		expectBool(decoded["authorized"].(bool), true, t)
+		expectString(decoded["returnShape"].(string), string(ReturnShapeBytes4), t)
+		expectBool(len(decoded["steps"].([]interface{})) == 4, true, t)
+	})
+
+	t.Run("Explaining should NOT record on the Authenticator", func(t *testing.T) {
+		authenticator := NewAuthenticator(nil, simulator)
+		authenticator.Explain("foo", dummySignature, wallet.Hex())
+		expectBool(authenticator.explanation == nil, true, t)
+	})
+}
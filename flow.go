@@ -0,0 +1,236 @@
+package dappauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"math/big"
+	"strings"
+
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/sha3"
+)
+
+// FlowUserDomainTag is the domain tag prepended to Flow user messages before hashing, right padded to 32 bytes.
+const FlowUserDomainTag = "FLOW-V0.0-user"
+
+// FlowWeightThreshold is the combined key weight that authorizes a Flow account.
+const FlowWeightThreshold = 1000
+
+// FlowSignatureAlgorithm is the signature algorithm of a Flow account key, as numbered by Flow.
+type FlowSignatureAlgorithm int
+
+const (
+	// FlowECDSAP256 is ECDSA on the NIST P-256 curve.
+	FlowECDSAP256 FlowSignatureAlgorithm = 2
+	// FlowECDSASecp256k1 is ECDSA on the secp256k1 curve.
+	FlowECDSASecp256k1 FlowSignatureAlgorithm = 3
+)
+
+// FlowHashAlgorithm is the hash algorithm of a Flow account key, as numbered by Flow.
+type FlowHashAlgorithm int
+
+const (
+	// FlowSHA2_256 is SHA2-256.
+	FlowSHA2_256 FlowHashAlgorithm = 1
+	// FlowSHA3_256 is SHA3-256.
+	FlowSHA3_256 FlowHashAlgorithm = 3
+	// FlowKeccak256 is Keccak-256.
+	FlowKeccak256 FlowHashAlgorithm = 6
+)
+
+// FlowAccountKey is a key of a Flow account.
+type FlowAccountKey struct {
+	Index     int
+	PublicKey []byte // The uncompressed point without prefix (64 bytes)
+	SigAlgo   FlowSignatureAlgorithm
+	HashAlgo  FlowHashAlgorithm
+	Weight    int
+	Revoked   bool
+}
+
+// FlowAccessAPI fetches the keys of Flow accounts, e.g. through the Flow access node API.
+type FlowAccessAPI interface {
+	GetAccountKeys(ctx context.Context, address string) ([]FlowAccountKey, error)
+}
+
+// FlowCompositeSignature is a signature of one account key, as returned by FCL signUserMessage.
+type FlowCompositeSignature struct {
+	Addr      string `json:"addr"`
+	KeyID     int    `json:"keyId"`
+	Signature string `json:"signature"`
+}
+
+// FlowKeySignature describes the check of one composite signature.
+type FlowKeySignature struct {
+	KeyIndex int
+	Weight   int
+	Valid    bool  // Whether the signature is valid for an unrevoked key of the account
+	Err      error // Why the signature is invalid (nil if it is valid)
+}
+
+// FlowStatus reports which keys of a Flow account signed and whether their weight met the threshold.
+type FlowStatus struct {
+	Signatures   []FlowKeySignature
+	Weight       int // The combined weight of the distinct keys with a valid signature
+	ThresholdMet bool
+}
+
+// FlowVerifier verifies Flow user signatures against the keys of Flow accounts.
+type FlowVerifier struct {
+	access FlowAccessAPI
+	ctx    context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+}
+
+// NewFlowVerifier creates a new FlowVerifier .
+func NewFlowVerifier(ctx context.Context, access FlowAccessAPI) *FlowVerifier {
+	// go-ethereum's clients don't accept a nil context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &FlowVerifier{
+		ctx:    ctx,
+		access: access,
+	}
+}
+
+// Verify checks the FCL composite signatures (a JSON array) of the challenge against the keys of the Flow account at addrHex.
+// Every signature must be valid, and the distinct keys that signed must reach FlowWeightThreshold.
+func (f *FlowVerifier) Verify(challenge, signature, addrHex string) (*Result, error) {
+	address, err := normalizeFlowAddress(addrHex)
+	if err != nil {
+		return nil, err
+	}
+
+	var signatures []FlowCompositeSignature
+	if err := json.Unmarshal([]byte(signature), &signatures); err != nil {
+		return nil, fmt.Errorf("Flow signature isn't a JSON array of composite signatures: %v", err)
+	}
+	if len(signatures) == 0 {
+		return nil, fmt.Errorf("Flow signature has no composite signatures")
+	}
+
+	keys, err := f.access.GetAccountKeys(f.ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("Flow account keys lookup errored with: '%v'", err)
+	}
+	keysByIndex := make(map[int]FlowAccountKey, len(keys))
+	for _, key := range keys {
+		keysByIndex[key.Index] = key
+	}
+
+	message := append(flowDomainTag(), decodeChallenge(challenge)...)
+	status := &FlowStatus{}
+	allValid := true
+	signed := make(map[int]bool)
+
+	for _, sig := range signatures {
+		keySig := FlowKeySignature{KeyIndex: sig.KeyID}
+		key, ok := keysByIndex[sig.KeyID]
+
+		sigAddress, err := normalizeFlowAddress(sig.Addr)
+		switch {
+		case err != nil || sigAddress != address:
+			keySig.Err = fmt.Errorf("signature is for address %q", sig.Addr)
+		case !ok:
+			keySig.Err = fmt.Errorf("account has no key %d", sig.KeyID)
+		case key.Revoked:
+			keySig.Err = fmt.Errorf("key %d is revoked", sig.KeyID)
+		default:
+			keySig.Weight = key.Weight
+			keySig.Valid, keySig.Err = verifyFlowSignature(key, message, sig.Signature)
+		}
+
+		if keySig.Valid && !signed[key.Index] {
+			signed[key.Index] = true
+			status.Weight += key.Weight
+		}
+		allValid = allValid && keySig.Valid
+		status.Signatures = append(status.Signatures, keySig)
+	}
+	status.ThresholdMet = status.Weight >= FlowWeightThreshold
+
+	result := &Result{Address: address, Flow: status}
+	if allValid && status.ThresholdMet {
+		result.Authorized, result.Method = true, MethodFlow
+	}
+	return result, nil
+}
+
+// verifyFlowSignature verifies the hex encoded r||s signature of the message with the key.
+func verifyFlowSignature(key FlowAccountKey, message []byte, signatureHex string) (bool, error) {
+	var curve elliptic.Curve
+	switch key.SigAlgo {
+	case FlowECDSAP256:
+		curve = elliptic.P256()
+	case FlowECDSASecp256k1:
+		curve = ethCrypto.S256()
+	default:
+		return false, fmt.Errorf("unsupported signature algorithm %d", key.SigAlgo)
+	}
+
+	var hasher hash.Hash
+	switch key.HashAlgo {
+	case FlowSHA2_256:
+		hasher = sha256.New()
+	case FlowSHA3_256:
+		hasher = sha3.New256()
+	case FlowKeccak256:
+		hasher = sha3.NewLegacyKeccak256()
+	default:
+		return false, fmt.Errorf("unsupported hash algorithm %d", key.HashAlgo)
+	}
+	hasher.Write(message)
+	digest := hasher.Sum(nil)
+
+	if len(key.PublicKey) != 64 {
+		return false, fmt.Errorf("invalid public key length %d", len(key.PublicKey))
+	}
+	pub := &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(key.PublicKey[:32]),
+		Y:     new(big.Int).SetBytes(key.PublicKey[32:]),
+	}
+	if !curve.IsOnCurve(pub.X, pub.Y) {
+		return false, fmt.Errorf("public key isn't on the curve")
+	}
+
+	sig, err := hex.DecodeString(strings.TrimPrefix(signatureHex, "0x"))
+	if err != nil {
+		return false, err
+	}
+	if len(sig) != 64 {
+		return false, fmt.Errorf("invalid signature length %d", len(sig))
+	}
+
+	if !ecdsa.Verify(pub, digest, new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+		return false, fmt.Errorf("signature doesn't match the key")
+	}
+	return true, nil
+}
+
+func flowDomainTag() []byte {
+	tag := make([]byte, 32)
+	copy(tag, FlowUserDomainTag)
+	return tag
+}
+
+// normalizeFlowAddress returns the 8 byte Flow address as 0x prefixed lowercase hex.
+func normalizeFlowAddress(addrHex string) (string, error) {
+	digits := strings.TrimPrefix(strings.ToLower(addrHex), "0x")
+	if len(digits)%2 == 1 {
+		digits = "0" + digits
+	}
+	b, err := hex.DecodeString(digits)
+	if err != nil || len(b) > 8 {
+		return "", fmt.Errorf("invalid Flow address %q", addrHex)
+	}
+	padded := make([]byte, 8)
+	copy(padded[8-len(b):], b)
+	return "0x" + hex.EncodeToString(padded), nil
+}
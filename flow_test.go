@@ -0,0 +1,156 @@
+package dappauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/sha3"
+)
+
+// fakeFlowAccess is an in-process fake of the Flow access API.
+type fakeFlowAccess struct {
+	accounts map[string][]FlowAccountKey
+}
+
+func (f *fakeFlowAccess) GetAccountKeys(ctx context.Context, address string) ([]FlowAccountKey, error) {
+	keys, ok := f.accounts[address]
+	if !ok {
+		return nil, fmt.Errorf("account %s not found", address)
+	}
+	return keys, nil
+}
+
+type flowTestKey struct {
+	key      *ecdsa.PrivateKey
+	hashAlgo FlowHashAlgorithm
+}
+
+func (k *flowTestKey) accountKey(index, weight int, sigAlgo FlowSignatureAlgorithm) FlowAccountKey {
+	publicKey := make([]byte, 64)
+	k.key.X.FillBytes(publicKey[:32])
+	k.key.Y.FillBytes(publicKey[32:])
+	return FlowAccountKey{Index: index, PublicKey: publicKey, SigAlgo: sigAlgo, HashAlgo: k.hashAlgo, Weight: weight}
+}
+
+func (k *flowTestKey) sign(challenge string, t *testing.T) string {
+	message := append(flowDomainTag(), decodeChallenge(challenge)...)
+	var digest []byte
+	if k.hashAlgo == FlowSHA3_256 {
+		hasher := sha3.New256()
+		hasher.Write(message)
+		digest = hasher.Sum(nil)
+	} else {
+		sum := sha256.Sum256(message)
+		digest = sum[:]
+	}
+	r, s, err := ecdsa.Sign(rand.Reader, k.key, digest)
+	checkError(err, t)
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return hex.EncodeToString(sig)
+}
+
+func compositeSignatures(sigs []FlowCompositeSignature, t *testing.T) string {
+	b, err := json.Marshal(sigs)
+	checkError(err, t)
+	return string(b)
+}
+
+func TestFlowVerifier(t *testing.T) {
+
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	checkError(err, t)
+	secpKey, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+	keyA := &flowTestKey{key: p256Key, hashAlgo: FlowSHA3_256}
+	keyB := &flowTestKey{key: secpKey, hashAlgo: FlowSHA2_256}
+
+	address := "0x01cf0e2f2f715450"
+	revoked := keyB.accountKey(2, 1000, FlowECDSASecp256k1)
+	revoked.Revoked = true
+	access := &fakeFlowAccess{accounts: map[string][]FlowAccountKey{
+		address: {
+			keyA.accountKey(0, 1000, FlowECDSAP256),
+			keyA.accountKey(1, 500, FlowECDSAP256),
+			keyB.accountKey(3, 500, FlowECDSASecp256k1),
+			revoked,
+		},
+	}}
+	verifier := NewFlowVerifier(nil, access)
+
+	t.Run("A full weight P-256 key should authorize", func(t *testing.T) {
+		signature := compositeSignatures([]FlowCompositeSignature{{Addr: address, KeyID: 0, Signature: keyA.sign("foo", t)}}, t)
+
+		result, err := verifier.Verify("foo", signature, "01cf0e2f2f715450")
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+		expectString(string(result.Method), string(MethodFlow), t)
+		expectString(result.Address, address, t)
+		expectBool(result.Flow.Weight == 1000, true, t)
+	})
+
+	t.Run("Partial weight keys should authorize together", func(t *testing.T) {
+		signature := compositeSignatures([]FlowCompositeSignature{
+			{Addr: address, KeyID: 1, Signature: keyA.sign("foo", t)},
+			{Addr: address, KeyID: 3, Signature: keyB.sign("foo", t)},
+		}, t)
+
+		result, err := verifier.Verify("foo", signature, address)
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+
+		signature = compositeSignatures([]FlowCompositeSignature{
+			{Addr: address, KeyID: 1, Signature: keyA.sign("foo", t)},
+			{Addr: address, KeyID: 1, Signature: keyA.sign("foo", t)},
+		}, t)
+		result, err = verifier.Verify("foo", signature, address)
+		checkError(err, t)
+		expectBool(result.Authorized, false, t)
+		expectBool(result.Flow.Weight == 500, true, t)
+	})
+
+	t.Run("Revoked keys should NOT authorize", func(t *testing.T) {
+		signature := compositeSignatures([]FlowCompositeSignature{{Addr: address, KeyID: 2, Signature: keyB.sign("foo", t)}}, t)
+
+		result, err := verifier.Verify("foo", signature, address)
+		checkError(err, t)
+		expectBool(result.Authorized, false, t)
+		expectBool(result.Flow.Signatures[0].Err != nil, true, t)
+	})
+
+	t.Run("Signatures of another challenge should NOT authorize", func(t *testing.T) {
+		signature := compositeSignatures([]FlowCompositeSignature{{Addr: address, KeyID: 0, Signature: keyA.sign("bar", t)}}, t)
+
+		result, err := verifier.Verify("foo", signature, address)
+		checkError(err, t)
+		expectBool(result.Authorized, false, t)
+	})
+
+	t.Run("An invalid signature should NOT authorize even with enough weight", func(t *testing.T) {
+		signature := compositeSignatures([]FlowCompositeSignature{
+			{Addr: address, KeyID: 0, Signature: keyA.sign("foo", t)},
+			{Addr: address, KeyID: 3, Signature: keyB.sign("bar", t)},
+		}, t)
+
+		result, err := verifier.Verify("foo", signature, address)
+		checkError(err, t)
+		expectBool(result.Authorized, false, t)
+		expectBool(result.Flow.ThresholdMet, true, t)
+	})
+
+	t.Run("Unknown accounts should error", func(t *testing.T) {
+		signature := compositeSignatures([]FlowCompositeSignature{{Addr: "0x02", KeyID: 0, Signature: keyA.sign("foo", t)}}, t)
+
+		_, err := verifier.Verify("foo", signature, "0x02")
+		expectBool(err != nil, true, t)
+	})
+}
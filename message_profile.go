@@ -0,0 +1,105 @@
+package dappauth
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// EthereumMessagePrefix is the EIP-191 personal message prefix of Ethereum.
+	EthereumMessagePrefix = "\x19Ethereum Signed Message:\n"
+	// TronMessagePrefix is the personal message prefix of Tron (TronLink signMessageV2).
+	TronMessagePrefix = "\x19TRON Signed Message:\n"
+)
+
+// tronAddressVersion is the version byte of base58check Tron addresses.
+const tronAddressVersion = 0x41
+
+// MessageProfile is the personal message format and address encoding of an EVM family chain.
+type MessageProfile struct {
+	Prefix        string                                       // Prepended to the decimal length of the message before hashing with keccak256
+	ParseAddress  func(address string) (common.Address, error) // Decodes the address argument (nil = hex)
+	FormatAddress func(addr common.Address) string             // Encodes Result.Address (nil = checksummed hex)
+}
+
+var (
+	// EthereumProfile is the profile of Ethereum and the EVM chains that share its prefix (default).
+	EthereumProfile = MessageProfile{Prefix: EthereumMessagePrefix}
+	// TronProfile is the profile of Tron, with base58check addresses (hex addresses are accepted as well).
+	TronProfile = MessageProfile{Prefix: TronMessagePrefix, ParseAddress: ParseTronAddress, FormatAddress: FormatTronAddress}
+)
+
+// WithMessageProfile sets the personal message prefix and address encoding, for EVM family chains other than Ethereum.
+// The prefix applies to signatures recovered by key (EOAs and delegation registry hot wallets), the ERC-1271 hash modes are unchanged.
+func WithMessageProfile(profile MessageProfile) Option {
+	return func(a *Authenticator) {
+		a.messageProfile = profile
+	}
+}
+
+// WithMessagePrefix sets the personal message prefix of an EVM fork with hex addresses, like WithMessageProfile .
+func WithMessagePrefix(prefix string) Option {
+	return WithMessageProfile(MessageProfile{Prefix: prefix})
+}
+
+// messagePrefix returns the personal message prefix of the profile.
+func (p MessageProfile) messagePrefix() string {
+	if p.Prefix == "" {
+		return EthereumMessagePrefix
+	}
+	return p.Prefix
+}
+
+// parseAddress decodes the address argument with the profile.
+func (p MessageProfile) parseAddress(address string) (common.Address, error) {
+	if p.ParseAddress == nil {
+		return common.HexToAddress(address), nil
+	}
+	return p.ParseAddress(address)
+}
+
+// formatAddress encodes the address with the profile.
+func (p MessageProfile) formatAddress(addr common.Address) string {
+	if p.FormatAddress == nil {
+		return addr.Hex()
+	}
+	return p.FormatAddress(addr)
+}
+
+// prefixedMessageHash is the keccak256 hash of the challenge with the personal message prefix and its length.
+func prefixedMessageHash(prefix, challenge string) []byte {
+	b := decodeChallenge(challenge)
+	msg := fmt.Sprintf("%s%d%s", prefix, len(b), b)
+	return ethCrypto.Keccak256([]byte(msg))
+}
+
+// ParseTronAddress decodes a base58check Tron address (T...), or a hex address with or without the 41 prefix.
+func ParseTronAddress(address string) (common.Address, error) {
+	if strings.HasPrefix(address, "T") {
+		version, payload, err := base58CheckDecode(address)
+		if err != nil {
+			return common.Address{}, fmt.Errorf("invalid Tron address %q: %v", address, err)
+		}
+		if version != tronAddressVersion || len(payload) != common.AddressLength {
+			return common.Address{}, fmt.Errorf("invalid Tron address %q", address)
+		}
+		return common.BytesToAddress(payload), nil
+	}
+
+	hexAddress := strings.TrimPrefix(address, "0x")
+	if len(hexAddress) == 2*(common.AddressLength+1) && strings.HasPrefix(hexAddress, "41") {
+		hexAddress = hexAddress[2:]
+	}
+	if !common.IsHexAddress(hexAddress) {
+		return common.Address{}, fmt.Errorf("invalid Tron address %q", address)
+	}
+	return common.HexToAddress(hexAddress), nil
+}
+
+// FormatTronAddress encodes the address as a base58check Tron address.
+func FormatTronAddress(addr common.Address) string {
+	return base58CheckEncode(tronAddressVersion, addr.Bytes())
+}
@@ -0,0 +1,94 @@
+package dappauth
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/dapperlabs/dappauth/dappauthtest"
+	"github.com/ethereum/go-ethereum/common"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestTronAddresses(t *testing.T) {
+	for address, expected := range map[string]common.Address{
+		"T9yD14Nj9j7xAB4dbGeiX9h8unkKHxuWwb":         {},
+		"TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t":         common.HexToAddress("0xa614f803b6fd780986a42c78ec9c7f77e6ded13c"),
+		"41a614f803b6fd780986a42c78ec9c7f77e6ded13c": common.HexToAddress("0xa614f803b6fd780986a42c78ec9c7f77e6ded13c"),
+		"0xa614f803b6fd780986a42c78ec9c7f77e6ded13c": common.HexToAddress("0xa614f803b6fd780986a42c78ec9c7f77e6ded13c"),
+	} {
+		addr, err := ParseTronAddress(address)
+		checkError(err, t)
+		expectString(addr.Hex(), expected.Hex(), t)
+	}
+	expectString(FormatTronAddress(common.HexToAddress("0xa614f803b6fd780986a42c78ec9c7f77e6ded13c")), "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", t)
+
+	for _, address := range []string{
+		"TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6T",         // bad checksum
+		"1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH",         // Bitcoin
+		"42a614f803b6fd780986a42c78ec9c7f77e6ded13c", // bad prefix
+	} {
+		_, err := ParseTronAddress(address)
+		expectBool(err != nil, true, t)
+	}
+}
+
+func TestMessageProfile(t *testing.T) {
+	key, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+	address := ethCrypto.PubkeyToAddress(key.PublicKey)
+
+	sign := func(prefix, msg string) string {
+		sig, err := ethCrypto.Sign(prefixedMessageHash(prefix, msg), key)
+		checkError(err, t)
+		sig[64] += 27
+		return hex.EncodeToString(sig)
+	}
+
+	t.Run("The Ethereum prefix should be the default", func(t *testing.T) {
+		expectString(hex.EncodeToString(prefixedMessageHash(EthereumMessagePrefix, "foo")), hex.EncodeToString(personalMessageHash("foo")), t)
+
+		authenticator := NewAuthenticator(nil, dappauthtest.NewWallet(address, 1))
+		isAuthorizedSigner, err := authenticator.IsAuthorizedSigner("foo", sign(EthereumMessagePrefix, "foo"), address.Hex())
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, true, t)
+
+		isAuthorizedSigner, err = authenticator.IsAuthorizedSigner("foo", sign(TronMessagePrefix, "foo"), address.Hex())
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, false, t)
+	})
+
+	t.Run("Tron signatures should be verified by Tron addresses", func(t *testing.T) {
+		authenticator := NewAuthenticator(nil, dappauthtest.NewWallet(address, 1), WithMessageProfile(TronProfile))
+		tronAddress := FormatTronAddress(address)
+
+		result, err := authenticator.Verify("foo", sign(TronMessagePrefix, "foo"), tronAddress)
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+		expectString(string(result.Method), string(MethodEOA), t)
+		expectString(result.Address, tronAddress, t)
+
+		// hex addresses are accepted, the result has the Tron address
+		result, err = authenticator.Verify("0x666f6f", sign(TronMessagePrefix, "foo"), "41"+hex.EncodeToString(address.Bytes()))
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+		expectString(result.Address, tronAddress, t)
+
+		_, err = authenticator.Verify("foo", sign(TronMessagePrefix, "foo"), "Tnotanaddress")
+		expectBool(err != nil, true, t)
+	})
+
+	t.Run("Custom prefixes should be verified by hex addresses", func(t *testing.T) {
+		prefix := "\x19Fork Signed Message:\n"
+		authenticator := NewAuthenticator(context.Background(), dappauthtest.NewWallet(address, 1), WithMessagePrefix(prefix))
+
+		result, err := authenticator.Verify("foo", sign(prefix, "foo"), address.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+		expectString(result.Address, address.Hex(), t)
+
+		result, err = authenticator.Verify("foo", sign(EthereumMessagePrefix, "foo"), address.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, false, t)
+	})
+}
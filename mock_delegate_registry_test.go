@@ -0,0 +1,89 @@
+package dappauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/dapperlabs/dappauth/ERCs"
+	"github.com/ethereum/go-ethereum"
+	ethAbi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// mockDelegation is a delegation from vault to delegate, for a contract or token if set.
+type mockDelegation struct {
+	delegate common.Address
+	vault    common.Address
+	contract *common.Address
+	tokenID  *big.Int
+}
+
+// mockDelegateRegistry emulates a v1 or v2 delegate registry, falling back to another caller for other contracts.
+type mockDelegateRegistry struct {
+	address     common.Address
+	version     DelegateRegistryVersion
+	delegations []mockDelegation
+	fallback    bind.ContractCaller
+	errorCheck  bool
+}
+
+func (m *mockDelegateRegistry) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return m.fallback.CodeAt(ctx, contract, blockNumber)
+}
+
+func (m *mockDelegateRegistry) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if *call.To != m.address {
+		return m.fallback.CallContract(ctx, call, blockNumber)
+	}
+	if m.errorCheck {
+		return nil, errors.New("Dummy error")
+	}
+
+	definition := ERCs.DelegateRegistryV1ABI
+	if m.version == DelegateRegistryV2 {
+		definition = ERCs.DelegateRegistryV2ABI
+	}
+	abi, err := ethAbi.JSON(strings.NewReader(definition))
+	if err != nil {
+		return nil, err
+	}
+	method, err := abi.MethodById(call.Data[:4])
+	if err != nil {
+		return nil, err
+	}
+	params, err := method.Inputs.Unpack(call.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+
+	delegate, vault := params[0].(common.Address), params[1].(common.Address)
+	var contract *common.Address
+	var tokenID *big.Int
+	switch method.Name {
+	case "checkDelegateForAll":
+	case "checkDelegateForContract":
+		c := params[2].(common.Address)
+		contract = &c
+	case "checkDelegateForToken", "checkDelegateForERC721":
+		c := params[2].(common.Address)
+		contract, tokenID = &c, params[3].(*big.Int)
+	default:
+		return nil, fmt.Errorf("Unexpected method %v", method.Name)
+	}
+
+	// like the real registries, wider delegations also match narrower checks
+	for _, d := range m.delegations {
+		if d.delegate != delegate || d.vault != vault {
+			continue
+		}
+		if d.contract == nil ||
+			(contract != nil && *d.contract == *contract && (d.tokenID == nil || (tokenID != nil && d.tokenID.Cmp(tokenID) == 0))) {
+			return method.Outputs.Pack(true)
+		}
+	}
+	return method.Outputs.Pack(false)
+}
@@ -0,0 +1,111 @@
+package dappauth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/dapperlabs/dappauth/ERCs"
+	"github.com/ethereum/go-ethereum"
+	ethAbi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var mockENSResolverAddress = common.HexToAddress("0x00000000000000000000000000000000000000e5")
+
+// mockENS emulates the ENS registry and a single public resolver, keyed by normalized names.
+type mockENS struct {
+	addrs    map[string]common.Address
+	reverse  map[common.Address]string
+	texts    map[string]map[string]string
+	fallback bind.ContractCaller // handles calls to any other contract (optional)
+}
+
+func (m *mockENS) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	if m.fallback != nil {
+		return m.fallback.CodeAt(ctx, contract, blockNumber)
+	}
+	return nil, fmt.Errorf("CodeAt not supported")
+}
+
+func (m *mockENS) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	switch *call.To {
+	case ENSRegistryAddress:
+		return m.callRegistry(call.Data)
+	case mockENSResolverAddress:
+		return m.callResolver(call.Data)
+	}
+	if m.fallback != nil {
+		return m.fallback.CallContract(ctx, call, blockNumber)
+	}
+	return nil, fmt.Errorf("Unexpected contract %v", call.To.Hex())
+}
+
+func (m *mockENS) nodes() map[[32]byte]string {
+	nodes := make(map[[32]byte]string)
+	for name := range m.addrs {
+		nodes[namehash(name)] = name
+	}
+	for name := range m.texts {
+		nodes[namehash(name)] = name
+	}
+	for addr := range m.reverse {
+		reverseName := strings.ToLower(strings.TrimPrefix(addr.Hex(), "0x")) + ".addr.reverse"
+		nodes[namehash(reverseName)] = reverseName
+	}
+	return nodes
+}
+
+func (m *mockENS) callRegistry(data []byte) ([]byte, error) {
+	abi, err := ethAbi.JSON(strings.NewReader(ERCs.ENSRegistryABI))
+	if err != nil {
+		return nil, err
+	}
+	method, err := abi.MethodById(data[:4])
+	if err != nil {
+		return nil, err
+	}
+	params, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := m.nodes()[params[0].([32]byte)]; ok {
+		return method.Outputs.Pack(mockENSResolverAddress)
+	}
+	return method.Outputs.Pack(common.Address{})
+}
+
+func (m *mockENS) callResolver(data []byte) ([]byte, error) {
+	abi, err := ethAbi.JSON(strings.NewReader(ERCs.ENSResolverABI))
+	if err != nil {
+		return nil, err
+	}
+	method, err := abi.MethodById(data[:4])
+	if err != nil {
+		return nil, err
+	}
+	params, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, err
+	}
+	name := m.nodes()[params[0].([32]byte)]
+
+	switch method.Name {
+	case "addr":
+		return method.Outputs.Pack(m.addrs[name])
+	case "name":
+		for addr, reverseName := range m.reverse {
+			if strings.HasPrefix(name, strings.ToLower(strings.TrimPrefix(addr.Hex(), "0x"))) {
+				return method.Outputs.Pack(reverseName)
+			}
+		}
+		return method.Outputs.Pack("")
+	case "text":
+		return method.Outputs.Pack(m.texts[name][params[1].(string)])
+	default:
+		return nil, fmt.Errorf("Unexpected method %v", method.Name)
+	}
+}
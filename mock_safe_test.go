@@ -0,0 +1,103 @@
+package dappauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/dapperlabs/dappauth/ERCs"
+	"github.com/ethereum/go-ethereum"
+	ethAbi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// mockSafe emulates a Gnosis Safe and its contract owners, which sign with an internal key over the SafeMessage data.
+type mockSafe struct {
+	address         common.Address
+	owners          []common.Address
+	threshold       int64
+	domainSeparator [32]byte
+	approvedHashes  map[common.Address]common.Hash
+	contractOwners  map[common.Address]*ecdsa.PublicKey
+	errorGetOwners  bool
+}
+
+func (m *mockSafe) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return nil, fmt.Errorf("CodeAt not supported")
+}
+
+func (m *mockSafe) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if *call.To == m.address {
+		return m.callSafe(call.Data)
+	}
+	if key, ok := m.contractOwners[*call.To]; ok {
+		return m.callContractOwner(key, call.Data)
+	}
+	return nil, fmt.Errorf("Unexpected contract %v", call.To.Hex())
+}
+
+func (m *mockSafe) callSafe(data []byte) ([]byte, error) {
+	abi, err := ethAbi.JSON(strings.NewReader(ERCs.GnosisSafeABI))
+	if err != nil {
+		return nil, err
+	}
+	method, err := abi.MethodById(data[:4])
+	if err != nil {
+		return nil, err
+	}
+
+	switch method.Name {
+	case "getOwners":
+		if m.errorGetOwners {
+			return nil, errors.New("Dummy error")
+		}
+		return method.Outputs.Pack(m.owners)
+	case "getThreshold":
+		return method.Outputs.Pack(big.NewInt(m.threshold))
+	case "domainSeparator":
+		return method.Outputs.Pack(m.domainSeparator)
+	case "approvedHashes":
+		params, err := method.Inputs.Unpack(data[4:])
+		if err != nil {
+			return nil, err
+		}
+		owner, hash := params[0].(common.Address), params[1].([32]byte)
+		if approved, ok := m.approvedHashes[owner]; ok && approved == hash {
+			return method.Outputs.Pack(big.NewInt(1))
+		}
+		return method.Outputs.Pack(big.NewInt(0))
+	default:
+		return nil, fmt.Errorf("Unexpected method %v", method.Name)
+	}
+}
+
+// "isValidSignature(bytes,bytes)" method call
+func (m *mockSafe) callContractOwner(key *ecdsa.PublicKey, data []byte) ([]byte, error) {
+	abi, err := ethAbi.JSON(strings.NewReader(ERCs.ERC1271LegacyABI))
+	if err != nil {
+		return nil, err
+	}
+	method, err := abi.MethodById(data[:4])
+	if err != nil {
+		return nil, err
+	}
+	params, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, err
+	}
+	messageData, sig := params[0].([]byte), params[1].([]byte)
+
+	if len(sig) == 65 {
+		adjSig := append([]byte{}, sig...)
+		adjSig[64] -= 27 // Transform V from 27/28 to 0/1 according to the yellow paper
+		recoveredKey, err := ethCrypto.SigToPub(ethCrypto.Keccak256(messageData), adjSig)
+		if err == nil && ethCrypto.PubkeyToAddress(*recoveredKey) == ethCrypto.PubkeyToAddress(*key) {
+			return method.Outputs.Pack(_ERC1271LegacyMagicValue)
+		}
+	}
+	return method.Outputs.Pack([4]byte{})
+}
@@ -0,0 +1,65 @@
+package dappauth
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Chain is another chain a contract wallet may be deployed on.
+type Chain struct {
+	ChainID uint64
+	Name    string
+	Caller  bind.ContractCaller // e.g. an ethclient.Client of the chain
+}
+
+// ChainProbe is the outcome of probing a chain for the contract wallet.
+type ChainProbe struct {
+	ChainID     uint64
+	Name        string
+	HasCode     bool        // Whether the address has code on the chain, ERC-1271 only runs if it does
+	Authorized  bool        // Whether the contract validated the signature on the chain
+	HashMode    HashMode    // The ERC-1271 hash mode that validated
+	ReturnShape ReturnShape // The shape of the ERC-1271 return data that validated
+	Err         error       // Why probing the chain failed (nil if it didn't)
+}
+
+// WithChains probes the chains in order for the contract wallet when the address has no code on the main chain,
+// running ERC-1271 on each chain that has code at the address. A wallet deployed on the main chain is only verified there,
+// as the same address can have other owners on other chains. It costs an extra CodeAt call per chain.
+func WithChains(chains ...Chain) Option {
+	return func(a *Authenticator) {
+		a.chains = append(a.chains, chains...)
+	}
+}
+
+// probeChains runs the contract path on every chain with code at the address, or returns nil if the address has code on the main chain.
+func (a *Authenticator) probeChains(challenge string, origSigBytes []byte, addr common.Address) ([]ChainProbe, error) {
+	code, err := a.cc.CodeAt(a.ctx, addr, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Main chain code lookup errored with: '%v'", err)
+	}
+	if len(code) > 0 {
+		return nil, nil
+	}
+
+	probes := make([]ChainProbe, len(a.chains))
+	for i, chain := range a.chains {
+		probe := ChainProbe{ChainID: chain.ChainID, Name: chain.Name}
+
+		// the chain is verified with the same options
+		onChain := *a
+		onChain.cc = chain.Caller
+
+		code, err := chain.Caller.CodeAt(a.ctx, addr, nil)
+		if err != nil {
+			probe.Err = fmt.Errorf("Chain %d code lookup errored with: '%v'", chain.ChainID, err)
+		} else if len(code) > 0 {
+			probe.HasCode = true
+			probe.Authorized, probe.HashMode, probe.ReturnShape, probe.Err = onChain.checkContract(challenge, origSigBytes, addr)
+		}
+		probes[i] = probe
+	}
+	return probes, nil
+}
@@ -0,0 +1,75 @@
+package dappauth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestMultiChain(t *testing.T) {
+
+	wallet := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	newChain := func(approvedChallenge string) *Simulator {
+		reader := &mockStateReader{
+			header:  &types.Header{Number: big.NewInt(12244000), GasLimit: 15000000, Difficulty: new(big.Int)},
+			code:    map[common.Address][]byte{},
+			storage: map[common.Address]map[common.Hash]common.Hash{},
+		}
+		if approvedChallenge != "" {
+			reader.code[wallet] = common.FromHex(approvedHashWalletCode)
+			reader.storage[wallet] = map[common.Hash]common.Hash{{}: scMessageHash(approvedChallenge)}
+		}
+		return NewSimulator(nil, reader, SimulatorConfig{})
+	}
+
+	chains := []Chain{
+		{ChainID: 10, Name: "optimism", Caller: newChain("")},
+		{ChainID: 8453, Name: "base", Caller: newChain("foo")},
+		{ChainID: 42161, Name: "arbitrum", Caller: newChain("bar")},
+	}
+
+	t.Run("Wallets deployed on other chains should be authorized", func(t *testing.T) {
+		authenticator := NewAuthenticator(nil, newChain(""), WithChains(chains...))
+
+		result, err := authenticator.Verify("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+
+		expectBool(result.Authorized, true, t)
+		expectString(string(result.Method), string(MethodERC1271), t)
+		expectBool(len(result.ChainProbes) == 3, true, t)
+		expectBool(result.ChainProbes[0].HasCode, false, t)
+		expectBool(result.ChainProbes[1].Authorized, true, t)
+		expectBool(result.ChainProbes[2].HasCode && !result.ChainProbes[2].Authorized, true, t)
+	})
+
+	t.Run("Wallets validating on no chain should NOT be authorized", func(t *testing.T) {
+		// the contract path error of the main chain, which has no code, is returned
+		authenticator := NewAuthenticator(nil, newChain(""), WithChains(chains...))
+
+		_, err := authenticator.Verify("qux", dummySignature, wallet.Hex())
+		expectBool(err != nil, true, t)
+	})
+
+	t.Run("Other chains should NOT be probed if the main chain validates", func(t *testing.T) {
+		authenticator := NewAuthenticator(nil, newChain("foo"), WithChains(chains...))
+
+		result, err := authenticator.Verify("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+
+		expectBool(result.Authorized, true, t)
+		expectBool(result.ChainProbes == nil, true, t)
+	})
+
+	t.Run("Other chains should NOT be probed if the wallet is deployed on the main chain", func(t *testing.T) {
+		// the main chain wallet rejects the signature its namesake on base accepts
+		authenticator := NewAuthenticator(nil, newChain("baz"), WithChains(chains...))
+
+		result, err := authenticator.Verify("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+
+		expectBool(result.Authorized, false, t)
+		expectBool(result.ChainProbes == nil, true, t)
+	})
+}
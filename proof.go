@@ -0,0 +1,176 @@
+package dappauth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	emptyCodeHash = ethCrypto.Keccak256Hash(nil)
+)
+
+// ProofReader fetches Merkle proofs of accounts and storage slots, like eth_getProof.
+type ProofReader interface {
+	GetProof(ctx context.Context, account common.Address, keys []common.Hash, blockNumber *big.Int) (*AccountProof, error)
+}
+
+// AccountProof is the eth_getProof result of an account. Only the proof nodes are trusted, the other fields are ignored.
+type AccountProof struct {
+	Address      common.Address  `json:"address"`
+	AccountProof []hexutil.Bytes `json:"accountProof"`
+	Balance      *hexutil.Big    `json:"balance"`
+	CodeHash     common.Hash     `json:"codeHash"`
+	Nonce        hexutil.Uint64  `json:"nonce"`
+	StorageHash  common.Hash     `json:"storageHash"`
+	StorageProof []StorageProof  `json:"storageProof"`
+}
+
+// StorageProof is the eth_getProof result of a storage slot.
+type StorageProof struct {
+	Key   string          `json:"key"`
+	Value *hexutil.Big    `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+// RPCProofReader implements ProofReader with eth_getProof.
+type RPCProofReader struct {
+	client *rpc.Client
+}
+
+// NewRPCProofReader creates a new RPCProofReader .
+func NewRPCProofReader(client *rpc.Client) *RPCProofReader {
+	return &RPCProofReader{client: client}
+}
+
+// GetProof implements ProofReader .
+func (r *RPCProofReader) GetProof(ctx context.Context, account common.Address, keys []common.Hash, blockNumber *big.Int) (*AccountProof, error) {
+	block := "latest"
+	if blockNumber != nil {
+		block = hexutil.EncodeBig(blockNumber)
+	}
+	if keys == nil {
+		keys = []common.Hash{}
+	}
+
+	var proof AccountProof
+	if err := r.client.CallContext(ctx, &proof, "eth_getProof", account, keys, block); err != nil {
+		return nil, err
+	}
+	return &proof, nil
+}
+
+// provenAccount is the RLP encoding of an account in the state trie.
+type provenAccount struct {
+	Nonce    uint64
+	Balance  *big.Int
+	Root     common.Hash
+	CodeHash []byte
+}
+
+// fetchProvenAccount fetches the account with its proof and checks it against the state root of the block.
+// The code is fetched separately and checked against the proven code hash.
+func (r *rpcSource) fetchProvenAccount(addr common.Address) (*sourceAccount, error) {
+	header := r.cache.header
+
+	proof, err := r.sim.config.ProofReader.GetProof(r.ctx, addr, nil, header.Number)
+	if err != nil {
+		return nil, fmt.Errorf("Simulation proof lookup of %s errored with: '%v'", addr.Hex(), err)
+	}
+	account, err := verifyAccountProof(header.Root, addr, proof.AccountProof)
+	if err != nil {
+		return nil, fmt.Errorf("Simulation proof of %s is invalid: %v", addr.Hex(), err)
+	}
+
+	var code []byte
+	if !bytes.Equal(account.CodeHash, emptyCodeHash.Bytes()) {
+		code, err = r.sim.sr.CodeAt(r.ctx, addr, header.Number)
+		if err != nil {
+			return nil, fmt.Errorf("Simulation code lookup of %s errored with: '%v'", addr.Hex(), err)
+		}
+		if !bytes.Equal(ethCrypto.Keccak256(code), account.CodeHash) {
+			return nil, fmt.Errorf("Simulation code of %s does not match its proven code hash", addr.Hex())
+		}
+	}
+
+	return &sourceAccount{
+		balance:     account.Balance,
+		nonce:       account.Nonce,
+		code:        code,
+		storageRoot: account.Root,
+	}, nil
+}
+
+// fetchProvenStorage fetches the slot with its proof and checks it against the proven storage root of the account.
+func (r *rpcSource) fetchProvenStorage(addr common.Address, slot common.Hash) (common.Hash, error) {
+	account, err := r.account(addr)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if account.storageRoot == (common.Hash{}) || account.storageRoot == types.EmptyRootHash {
+		return common.Hash{}, nil
+	}
+
+	proof, err := r.sim.config.ProofReader.GetProof(r.ctx, addr, []common.Hash{slot}, r.cache.header.Number)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("Simulation proof lookup of %s errored with: '%v'", addr.Hex(), err)
+	}
+	if len(proof.StorageProof) != 1 {
+		return common.Hash{}, fmt.Errorf("Simulation proof of %s has %d storage proofs, expected 1", addr.Hex(), len(proof.StorageProof))
+	}
+
+	value, err := verifyStorageProof(account.storageRoot, slot, proof.StorageProof[0].Proof)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("Simulation storage proof of %s is invalid: %v", addr.Hex(), err)
+	}
+	return value, nil
+}
+
+// verifyAccountProof checks the proof against the state root, and returns the proven account (empty if it doesn't exist).
+func verifyAccountProof(root common.Hash, addr common.Address, proof []hexutil.Bytes) (*provenAccount, error) {
+	value, err := trie.VerifyProof(root, ethCrypto.Keccak256(addr.Bytes()), proofDB(proof))
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return &provenAccount{Balance: new(big.Int), Root: types.EmptyRootHash, CodeHash: emptyCodeHash.Bytes()}, nil
+	}
+
+	var account provenAccount
+	if err := rlp.DecodeBytes(value, &account); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// verifyStorageProof checks the proof against the storage root, and returns the proven value (zero if it isn't set).
+func verifyStorageProof(root common.Hash, slot common.Hash, proof []hexutil.Bytes) (common.Hash, error) {
+	value, err := trie.VerifyProof(root, ethCrypto.Keccak256(slot.Bytes()), proofDB(proof))
+	if err != nil || value == nil {
+		return common.Hash{}, err
+	}
+
+	var content []byte
+	if err := rlp.DecodeBytes(value, &content); err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(content), nil
+}
+
+func proofDB(proof []hexutil.Bytes) *memorydb.Database {
+	db := memorydb.New()
+	for _, node := range proof {
+		db.Put(ethCrypto.Keccak256(node), node)
+	}
+	return db
+}
@@ -0,0 +1,216 @@
+package dappauth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// mockProofReader serves proofs of a real state trie.
+type mockProofReader struct {
+	db    state.Database
+	root  common.Hash
+	state *state.StateDB
+}
+
+func (m *mockProofReader) GetProof(ctx context.Context, account common.Address, keys []common.Hash, blockNumber *big.Int) (*AccountProof, error) {
+	tr, err := m.db.OpenTrie(m.root)
+	if err != nil {
+		return nil, err
+	}
+	proof := &AccountProof{Address: account}
+	if err := tr.Prove(ethCrypto.Keccak256(account.Bytes()), (*proofList)(&proof.AccountProof)); err != nil {
+		return nil, err
+	}
+
+	storageTrie, err := m.db.OpenStorageTrie(m.root, account, m.state.GetStorageRoot(account), tr)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		storageProof := StorageProof{Key: key.Hex()}
+		if err := storageTrie.Prove(ethCrypto.Keccak256(key.Bytes()), (*proofList)(&storageProof.Proof)); err != nil {
+			return nil, err
+		}
+		proof.StorageProof = append(proof.StorageProof, storageProof)
+	}
+	return proof, nil
+}
+
+// proofList collects the nodes of a proof, like eth_getProof.
+type proofList []hexutil.Bytes
+
+func (l *proofList) Put(key []byte, value []byte) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func (l *proofList) Delete(key []byte) error {
+	panic("not supported")
+}
+
+// newProvenState commits the accounts and returns the state root and a reader of the committed state.
+func newProvenState(t *testing.T, code map[common.Address][]byte, storage map[common.Address]map[common.Hash]common.Hash) (common.Hash, *mockProofReader) {
+	db := state.NewDatabaseForTesting()
+	statedb, err := state.New(types.EmptyRootHash, db)
+	checkError(err, t)
+
+	for addr, c := range code {
+		statedb.SetCode(addr, c, tracing.CodeChangeUnspecified)
+	}
+	for addr, slots := range storage {
+		for slot, value := range slots {
+			statedb.SetState(addr, slot, value)
+		}
+	}
+	root, err := statedb.Commit(0, true, false)
+	checkError(err, t)
+
+	committed, err := state.New(root, db)
+	checkError(err, t)
+	return root, &mockProofReader{db: db, root: root, state: committed}
+}
+
+// returns the ERC-1271 magic value for any hash
+var forgedWalletCode = common.FromHex("0x631626ba7e60e01b60005260206000f3")
+
+func TestProvenSimulation(t *testing.T) {
+
+	wallet := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	walletCode := common.FromHex(approvedHashWalletCode)
+	root, proofReader := newProvenState(t,
+		map[common.Address][]byte{wallet: walletCode},
+		map[common.Address]map[common.Hash]common.Hash{wallet: {{}: scMessageHash("foo")}},
+	)
+
+	// the StateReader is only trusted for code (checked against the proven code hash), and lies about storage
+	newReader := func(code []byte) *mockStateReader {
+		return &mockStateReader{
+			header:  &types.Header{Number: big.NewInt(12244000), GasLimit: 15000000, Difficulty: new(big.Int), Root: root},
+			code:    map[common.Address][]byte{wallet: code},
+			storage: map[common.Address]map[common.Hash]common.Hash{wallet: {{}: scMessageHash("bar")}},
+		}
+	}
+
+	t.Run("Proven wallets should authorize signatures they validate", func(t *testing.T) {
+		reader := newReader(walletCode)
+		simulator := NewSimulator(nil, reader, SimulatorConfig{ProofReader: proofReader, TrustedHeader: reader.header})
+
+		result, err := simulator.Verify("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+
+		expectBool(result.Authorized, true, t)
+		expectBool(result.Simulation.Proven, true, t)
+	})
+
+	t.Run("Simulations that read overridden state should NOT be proven", func(t *testing.T) {
+		reader := newReader(walletCode)
+		simulator := NewSimulator(nil, reader, SimulatorConfig{
+			ProofReader:   proofReader,
+			TrustedHeader: reader.header,
+			Overrides: map[common.Address]StateOverride{
+				wallet: {Storage: map[common.Hash]common.Hash{{}: scMessageHash("bar")}},
+			},
+		})
+
+		result, err := simulator.Verify("bar", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+		expectBool(result.Simulation.Proven, false, t)
+
+		// overrides of accounts the call doesn't read leave it proven
+		simulator = NewSimulator(nil, reader, SimulatorConfig{
+			ProofReader:   proofReader,
+			TrustedHeader: reader.header,
+			Overrides: map[common.Address]StateOverride{
+				common.HexToAddress("0x00000000000000000000000000000000000000bb"): {Code: forgedWalletCode},
+			},
+		})
+
+		result, err = simulator.Verify("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+		expectBool(result.Simulation.Proven, true, t)
+	})
+
+	t.Run("Storage should NOT be trusted from the StateReader", func(t *testing.T) {
+		reader := newReader(walletCode)
+		simulator := NewSimulator(nil, reader, SimulatorConfig{ProofReader: proofReader, TrustedHeader: reader.header})
+
+		result, err := simulator.Verify("bar", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, false, t)
+	})
+
+	t.Run("A trusted state root should be matched to the fetched header", func(t *testing.T) {
+		simulator := NewSimulator(nil, newReader(walletCode), SimulatorConfig{ProofReader: proofReader, TrustedStateRoot: root})
+
+		result, err := simulator.Verify("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+		expectBool(result.Simulation.Proven, false, t)
+
+		simulator = NewSimulator(nil, newReader(walletCode), SimulatorConfig{ProofReader: proofReader, TrustedStateRoot: common.HexToHash("0x01")})
+		_, err = simulator.Verify("foo", dummySignature, wallet.Hex())
+		expectBool(err != nil, true, t)
+	})
+
+	t.Run("The block context should only be trusted from a trusted header", func(t *testing.T) {
+		// returns the ERC-1271 magic value before the timestamp 1900000000
+		deadlineWalletCode := common.FromHex("0x63713fb3004210600b57005b631626ba7e60e01b60005260206000f3")
+		root, proofReader := newProvenState(t, map[common.Address][]byte{wallet: deadlineWalletCode}, nil)
+
+		// the StateReader lies about the timestamp of the block
+		reader := newReader(deadlineWalletCode)
+		reader.header.Root, reader.header.Time = root, 1000000000
+		trustedHeader := types.CopyHeader(reader.header)
+		trustedHeader.Time = 2000000000
+
+		simulator := NewSimulator(nil, reader, SimulatorConfig{ProofReader: proofReader, TrustedStateRoot: root})
+		result, err := simulator.Verify("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+		expectBool(result.Simulation.Proven, false, t)
+
+		simulator = NewSimulator(nil, reader, SimulatorConfig{ProofReader: proofReader, TrustedHeader: trustedHeader})
+		result, err = simulator.Verify("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, false, t)
+		expectBool(result.Simulation.Proven, true, t)
+	})
+
+	t.Run("Code that doesn't match the proven code hash should error", func(t *testing.T) {
+		reader := newReader(forgedWalletCode)
+		simulator := NewSimulator(nil, reader, SimulatorConfig{ProofReader: proofReader, TrustedHeader: reader.header})
+
+		_, err := simulator.Verify("bar", dummySignature, wallet.Hex())
+		expectBool(err != nil, true, t)
+	})
+
+	t.Run("Proofs of another state should error", func(t *testing.T) {
+		_, otherProofReader := newProvenState(t,
+			map[common.Address][]byte{wallet: walletCode},
+			map[common.Address]map[common.Hash]common.Hash{wallet: {{}: scMessageHash("bar")}},
+		)
+		reader := newReader(walletCode)
+		simulator := NewSimulator(nil, reader, SimulatorConfig{ProofReader: otherProofReader, TrustedHeader: reader.header})
+
+		_, err := simulator.Verify("bar", dummySignature, wallet.Hex())
+		expectBool(err != nil, true, t)
+	})
+
+	t.Run("Only the trusted block should be simulated", func(t *testing.T) {
+		reader := newReader(walletCode)
+		simulator := NewSimulator(nil, reader, SimulatorConfig{ProofReader: proofReader, TrustedHeader: reader.header})
+
+		_, err := simulator.CodeAt(context.Background(), wallet, big.NewInt(1))
+		expectBool(err != nil, true, t)
+	})
+}
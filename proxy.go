@@ -0,0 +1,85 @@
+package dappauth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// EIP1967ImplementationSlot is the storage slot of the implementation of EIP-1967 proxies.
+	EIP1967ImplementationSlot = common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+	// EIP1967AdminSlot is the storage slot of the admin of EIP-1967 proxies.
+	EIP1967AdminSlot = common.HexToHash("0xb53127684a568b3173ae13b9f8a6016e243e63b6e8ee1178d6a717850b5d6103")
+
+	_EIP1167Prefix = common.FromHex("0x363d3d373d3d3d363d73")
+	_EIP1167Suffix = common.FromHex("0x5af43d82803e903d91602b57fd5bf3")
+)
+
+// ProxyType is the kind of proxy a wallet is deployed as.
+type ProxyType string
+
+const (
+	// ProxyTypeEIP7702 is an EOA delegated to code via EIP-7702.
+	ProxyTypeEIP7702 ProxyType = "eip7702"
+	// ProxyTypeEIP1167 is an EIP-1167 minimal proxy.
+	ProxyTypeEIP1167 ProxyType = "eip1167"
+	// ProxyTypeEIP1967 is a proxy with its implementation in the EIP-1967 slot.
+	ProxyTypeEIP1967 ProxyType = "eip1967"
+	// ProxyTypeSafe is a Safe proxy with a known singleton in its first slot.
+	ProxyTypeSafe ProxyType = "safe"
+)
+
+// storageReader is implemented by ContractCallers that can read storage, like ethclient.Client and Simulator .
+type storageReader interface {
+	StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error)
+}
+
+// resolveProxy returns the implementation the code at the address delegates to, or nil if it isn't a proxy.
+// EIP-7702 delegations and EIP-1167 minimal proxies are detected from the code, EIP-1967 proxies only if the ContractCaller can read storage.
+func (a *Authenticator) resolveProxy(addr common.Address, code []byte) (ProxyType, *common.Address, error) {
+	if delegate := parseDelegation(code); delegate != nil {
+		return ProxyTypeEIP7702, delegate, nil
+	}
+	if implementation := parseMinimalProxy(code); implementation != nil {
+		return ProxyTypeEIP1167, implementation, nil
+	}
+
+	implementation, err := a.addressAt(addr, EIP1967ImplementationSlot)
+	if err != nil {
+		return "", nil, fmt.Errorf("EIP-1967 implementation lookup errored with: '%v'", err)
+	}
+	if implementation != nil {
+		return ProxyTypeEIP1967, implementation, nil
+	}
+	return "", nil, nil
+}
+
+// addressAt returns the address stored in the slot, or nil if it is empty or the ContractCaller can't read storage.
+func (a *Authenticator) addressAt(addr common.Address, slot common.Hash) (*common.Address, error) {
+	sr, ok := a.cc.(storageReader)
+	if !ok {
+		return nil, nil
+	}
+	value, err := sr.StorageAt(a.ctx, addr, slot, nil)
+	if err != nil {
+		return nil, err
+	}
+	if stored := common.BytesToAddress(value); stored != (common.Address{}) {
+		return &stored, nil
+	}
+	return nil, nil
+}
+
+// parseMinimalProxy returns the implementation of EIP-1167 minimal proxy code, or nil if it isn't one.
+func parseMinimalProxy(code []byte) *common.Address {
+	if len(code) != len(_EIP1167Prefix)+common.AddressLength+len(_EIP1167Suffix) ||
+		!bytes.HasPrefix(code, _EIP1167Prefix) || !bytes.HasSuffix(code, _EIP1167Suffix) {
+		return nil
+	}
+	implementation := common.BytesToAddress(code[len(_EIP1167Prefix) : len(_EIP1167Prefix)+common.AddressLength])
+	return &implementation
+}
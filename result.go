@@ -0,0 +1,58 @@
+package dappauth
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Method identifies the verification flow that produced a Result .
+type Method string
+
+const (
+	// MethodEOA means the signature was recovered directly to the address.
+	MethodEOA Method = "eoa"
+	// MethodERC1271 means the contract at the address approved the signature via isValidSignature.
+	MethodERC1271 Method = "erc1271"
+	// MethodSafe means the owners of a Gnosis Safe at the address met its threshold.
+	MethodSafe Method = "safe"
+	// MethodDelegateRegistry means a hot wallet holding a delegation registry delegation for the address signed.
+	MethodDelegateRegistry Method = "delegate_registry"
+	// MethodFlow means the keys of a Flow account that signed reached the weight threshold.
+	MethodFlow Method = "flow"
+	// MethodSolana means the ed25519 key of the Solana address signed.
+	MethodSolana Method = "solana"
+	// MethodBIP137 means a BIP-137 compact signature recovered to the key of the Bitcoin address.
+	MethodBIP137 Method = "bip137"
+	// MethodBIP322 means a BIP-322 simple signature spent the output of the Bitcoin address.
+	MethodBIP322 Method = "bip322"
+	// MethodADR036 means an ADR-036 signature of the sign doc was signed by the key of the Cosmos address.
+	MethodADR036 Method = "adr036"
+	// MethodAll means every verifier of a StrategyAll Strategy authorized.
+	MethodAll Method = "all"
+)
+
+// Result holds the detailed outcome of a verification.
+type Result struct {
+	Authorized  bool            // Whether the signature authorizes Address
+	Address     string          // The address that was verified
+	ENSName     string          // The normalized ENS name Address was resolved from (empty if given an address)
+	Method      Method          // The flow that authorized the signature (empty if not authorized)
+	HashMode    HashMode        // The ERC-1271 hash mode that validated (ERC-1271 only)
+	ReturnShape ReturnShape     // The shape of the ERC-1271 return data that validated (ERC-1271 only)
+	DelegatedTo *common.Address // The EIP-7702 delegate of a delegated EOA (nil otherwise, or if detection is disabled)
+	Safe        *SafeStatus     // Owner and threshold details (Safe verification only)
+	Flow        *FlowStatus     // Key and weight details (Flow verification only)
+	SIWS        *SIWSMessage    // The parsed Sign-In With Solana message (Solana verification of SIWS messages only)
+	SIWx        *SIWxMessage    // The parsed Sign-In With X message (SIWx only)
+	Account     string          // The CAIP-10 account of Address (SIWx only)
+
+	VaultDelegation *VaultDelegation // The delegation the signing hot wallet holds for Address (delegate registry only)
+	Simulation      *Simulation      // The isValidSignature call run in the simulated EVM (Simulator only)
+	ChainProbes     []ChainProbe     // The other chains probed for the contract wallet, authorized if any validated (multi-chain only)
+	Results         []*Result        // The result of each verifier run (StrategyAll only)
+
+	Wallet    *WalletInfo // The wallet at Address on the contract path (if enabled and Address has code)
+	WalletErr error       // Why the Wallet detection failed (nil if it didn't)
+
+	ENSProfile    *ENSProfile // The primary ENS name and avatar of an authorized Address (if enabled and set)
+	ENSProfileErr error       // Why the ENSProfile lookup failed (nil if it didn't)
+}
@@ -0,0 +1,155 @@
+package dappauth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	ethAbi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	_ErrorSelector = [4]byte{8, 195, 121, 160} // 0x08c379a0 Error(string)
+	_PanicSelector = [4]byte{78, 72, 123, 113} // 0x4e487b71 Panic(uint256)
+
+	panicReasons = map[uint64]string{
+		0x01: "assertion failed",
+		0x11: "arithmetic overflow or underflow",
+		0x12: "division or modulo by zero",
+		0x21: "invalid enum value",
+		0x22: "invalid storage byte array",
+		0x31: "pop on empty array",
+		0x32: "array index out of bounds",
+		0x41: "out of memory",
+		0x51: "call to zero-initialized function",
+	}
+)
+
+// RevertError is returned when an ERC-1271 call reverted, with its revert data decoded where possible.
+type RevertError struct {
+	Contract  common.Address
+	Data      []byte        // The raw revert data (empty if the node didn't return it)
+	Reason    string        // The message of an Error(string) revert
+	PanicCode *big.Int      // The code of a Panic(uint256) revert
+	ErrorName string        // The name of a custom error found in the ErrorRegistry
+	ErrorArgs []interface{} // The decoded arguments of the custom error
+}
+
+func (e *RevertError) Error() string {
+	switch {
+	case e.Reason != "":
+		return fmt.Sprintf("execution reverted: %s", e.Reason)
+	case e.PanicCode != nil:
+		reason, ok := panicReasons[e.PanicCode.Uint64()]
+		if !ok || !e.PanicCode.IsUint64() {
+			reason = "unknown panic"
+		}
+		return fmt.Sprintf("execution reverted: panic 0x%x (%s)", e.PanicCode, reason)
+	case e.ErrorName != "":
+		args := make([]string, len(e.ErrorArgs))
+		for i, arg := range e.ErrorArgs {
+			args[i] = fmt.Sprintf("%v", arg)
+		}
+		return fmt.Sprintf("execution reverted: %s(%s)", e.ErrorName, strings.Join(args, ", "))
+	case len(e.Data) > 0:
+		return fmt.Sprintf("execution reverted: %s", hexutil.Encode(e.Data))
+	default:
+		return "execution reverted"
+	}
+}
+
+// ErrorRegistry decodes custom Solidity errors by their selector.
+type ErrorRegistry struct {
+	errors map[[4]byte]customError
+}
+
+type customError struct {
+	name   string
+	inputs ethAbi.Arguments
+}
+
+// NewErrorRegistry creates a new ErrorRegistry .
+func NewErrorRegistry() *ErrorRegistry {
+	return &ErrorRegistry{errors: make(map[[4]byte]customError)}
+}
+
+// RegisterABI registers the errors of a contract ABI JSON, ignoring its other entries.
+func (r *ErrorRegistry) RegisterABI(abiJSON string) error {
+	var entries []struct {
+		Type   string
+		Name   string
+		Inputs ethAbi.Arguments
+	}
+	if err := json.Unmarshal([]byte(abiJSON), &entries); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.Type != "error" {
+			continue
+		}
+		types := make([]string, len(entry.Inputs))
+		for i, input := range entry.Inputs {
+			types[i] = input.Type.String()
+		}
+		var selector [4]byte
+		copy(selector[:], ethCrypto.Keccak256([]byte(fmt.Sprintf("%s(%s)", entry.Name, strings.Join(types, ",")))))
+		r.errors[selector] = customError{name: entry.Name, inputs: entry.Inputs}
+	}
+	return nil
+}
+
+// WithErrorRegistry decodes custom errors of reverted ERC-1271 calls with the registry.
+func WithErrorRegistry(registry *ErrorRegistry) Option {
+	return func(a *Authenticator) {
+		a.errorRegistry = registry
+	}
+}
+
+// revertError returns a RevertError for a reverted call error, or nil if it didn't revert.
+// The revert data is taken from the error data of the JSON-RPC error, or of a Simulator error.
+func revertError(err error, contract common.Address, registry *ErrorRegistry) *RevertError {
+	if !errors.Is(err, vm.ErrExecutionReverted) && !strings.Contains(err.Error(), vm.ErrExecutionReverted.Error()) {
+		return nil
+	}
+
+	revert := &RevertError{Contract: contract}
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if data, ok := dataErr.ErrorData().(string); ok {
+			revert.Data, _ = hexutil.Decode(data)
+		}
+	}
+	if len(revert.Data) < 4 {
+		return revert
+	}
+
+	var selector [4]byte
+	copy(selector[:], revert.Data[:4])
+	switch selector {
+	case _ErrorSelector:
+		revert.Reason, _ = ethAbi.UnpackRevert(revert.Data)
+	case _PanicSelector:
+		if len(revert.Data) == 36 {
+			revert.PanicCode = new(big.Int).SetBytes(revert.Data[4:])
+		}
+	default:
+		if registry == nil {
+			break
+		}
+		if custom, ok := registry.errors[selector]; ok {
+			args, err := custom.inputs.Unpack(revert.Data[4:])
+			if err == nil {
+				revert.ErrorName, revert.ErrorArgs = custom.name, args
+			}
+		}
+	}
+	return revert
+}
@@ -0,0 +1,86 @@
+package dappauth
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	ethAbi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+const invalidSignerABI = `[{"type":"error","name":"InvalidSigner","inputs":[{"name":"signer","type":"address"}]},{"type":"function","name":"foo","inputs":[]}]`
+
+// revertingCode returns code that reverts with the data.
+func revertingCode(data []byte) []byte {
+	code := []byte{
+		byte(0x60), byte(len(data)), byte(0x60), 12, byte(0x60), 0, byte(0x39), // CODECOPY(0, 12, len)
+		byte(0x60), byte(len(data)), byte(0x60), 0, byte(0xfd), // REVERT(0, len)
+	}
+	return append(code, data...)
+}
+
+func encodeError(t *testing.T, signature string, typ string, value interface{}) []byte {
+	abiType, err := ethAbi.NewType(typ, "", nil)
+	checkError(err, t)
+	args, err := ethAbi.Arguments{{Type: abiType}}.Pack(value)
+	checkError(err, t)
+	return append(ethCrypto.Keccak256([]byte(signature))[:4], args...)
+}
+
+func TestRevertError(t *testing.T) {
+
+	wallet := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	signer := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+
+	verify := func(revertData []byte, opts ...Option) *RevertError {
+		simulator := NewSimulator(nil, &mockStateReader{
+			header: &types.Header{Number: big.NewInt(12244000), GasLimit: 15000000, Difficulty: new(big.Int)},
+			code:   map[common.Address][]byte{wallet: revertingCode(revertData)},
+		}, SimulatorConfig{})
+		authenticator := NewAuthenticator(nil, simulator, opts...)
+
+		_, err := authenticator.IsAuthorizedSigner("foo", dummySignature, wallet.Hex())
+		var revert *RevertError
+		if !errors.As(err, &revert) {
+			t.Fatalf("expected a RevertError, got: %v", err)
+		}
+		expectString(revert.Contract.Hex(), wallet.Hex(), t)
+		return revert
+	}
+
+	t.Run("Error(string) reverts should be decoded", func(t *testing.T) {
+		revert := verify(encodeError(t, "Error(string)", "string", "bad signature"))
+
+		expectString(revert.Reason, "bad signature", t)
+		expectString(revert.Error(), "execution reverted: bad signature", t)
+	})
+
+	t.Run("Panic(uint256) reverts should be decoded", func(t *testing.T) {
+		revert := verify(encodeError(t, "Panic(uint256)", "uint256", big.NewInt(0x11)))
+
+		expectBool(revert.PanicCode.Int64() == 0x11, true, t)
+		expectString(revert.Error(), "execution reverted: panic 0x11 (arithmetic overflow or underflow)", t)
+	})
+
+	t.Run("Registered custom errors should be decoded", func(t *testing.T) {
+		registry := NewErrorRegistry()
+		checkError(registry.RegisterABI(invalidSignerABI), t)
+		revert := verify(encodeError(t, "InvalidSigner(address)", "address", signer), WithErrorRegistry(registry))
+
+		expectString(revert.ErrorName, "InvalidSigner", t)
+		expectString(revert.ErrorArgs[0].(common.Address).Hex(), signer.Hex(), t)
+		expectString(revert.Error(), "execution reverted: InvalidSigner("+signer.Hex()+")", t)
+	})
+
+	t.Run("Unknown custom errors should report their data", func(t *testing.T) {
+		data := encodeError(t, "InvalidSigner(address)", "address", signer)
+		revert := verify(data)
+
+		expectString(revert.ErrorName, "", t)
+		expectBool(strings.HasSuffix(revert.Error(), common.Bytes2Hex(data)), true, t)
+	})
+}
@@ -0,0 +1,253 @@
+package dappauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/dapperlabs/dappauth/ERCs"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	_ERC1271LegacyMagicValue = [4]byte{32, 193, 59, 11} // 0x20c13b0b
+
+	// keccak256("SafeMessage(bytes message)")
+	_SafeMessageTypeHash = common.HexToHash("0x60b3cbf8b4a223d68d641b3b6ddf9a298e7f33710cf3d3a9d1146b5a6150fbca")
+)
+
+// SafeSignatureType is the encoding of a single owner signature within a concatenated Safe signature.
+type SafeSignatureType string
+
+const (
+	// SafeSignatureECDSA is an owner signature over the SafeMessage hash (v is 27/28).
+	SafeSignatureECDSA SafeSignatureType = "ecdsa"
+	// SafeSignatureEthSign is an owner eth_sign signature over the SafeMessage hash (v is 31/32).
+	SafeSignatureEthSign SafeSignatureType = "eth_sign"
+	// SafeSignatureContract is a signature validated by an owner contract via ERC-1271 (v is 0).
+	SafeSignatureContract SafeSignatureType = "contract"
+	// SafeSignatureApprovedHash is a SafeMessage hash approved on-chain by an owner (v is 1).
+	SafeSignatureApprovedHash SafeSignatureType = "approved_hash"
+)
+
+// SafeSignature describes one owner signature split out of a concatenated Safe signature.
+type SafeSignature struct {
+	Type  SafeSignatureType
+	Owner common.Address // The owner the signature recovered to, or claims to be from
+	Valid bool           // Whether the signature is valid and Owner is a current owner
+	Err   error          // Why the signature could not be checked (nil if it could)
+}
+
+// SafeStatus reports which owners of a Safe signed and whether its threshold was met.
+type SafeStatus struct {
+	Owners       []common.Address
+	Threshold    uint64
+	MessageHash  common.Hash // The SafeMessage EIP-712 hash the owners signed
+	Signatures   []SafeSignature
+	Signed       []common.Address // Owners with a valid signature, in signature order
+	Missing      []common.Address // Owners without a valid signature, in owner order
+	ThresholdMet bool
+}
+
+// SafeVerifier verifies Gnosis Safe signatures natively by reading the Safe's owners and threshold.
+type SafeVerifier struct {
+	cc  bind.ContractCaller
+	ctx context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+}
+
+// NewSafeVerifier creates a new SafeVerifier .
+func NewSafeVerifier(ctx context.Context, cc bind.ContractCaller) *SafeVerifier {
+	// go-ethereum's clients don't accept a nil context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &SafeVerifier{
+		ctx: ctx,
+		cc:  cc,
+	}
+}
+
+// Verify checks the owner signatures concatenated in signature against the Safe at addrHex.
+// As with IsAuthorizedSigner, the signed SafeMessage wraps the keccak256 hash of the challenge.
+func (s *SafeVerifier) Verify(challenge, signature, addrHex string) (*Result, error) {
+	addr := common.HexToAddress(addrHex)
+
+	safeCaller, err := ERCs.NewGnosisSafeCaller(addr, s.cc)
+	if err != nil {
+		return nil, err
+	}
+
+	session := ERCs.GnosisSafeCallerSession{
+		Contract: safeCaller,
+		CallOpts: bind.CallOpts{
+			Pending: false,
+			Context: s.ctx,
+		},
+	}
+
+	owners, err := session.GetOwners()
+	if err != nil {
+		return nil, fmt.Errorf("Safe getOwners call errored with: '%v'", err)
+	}
+	threshold, err := session.GetThreshold()
+	if err != nil {
+		return nil, fmt.Errorf("Safe getThreshold call errored with: '%v'", err)
+	}
+	if !threshold.IsUint64() {
+		return nil, fmt.Errorf("Safe threshold %v is out of range", threshold)
+	}
+	domainSeparator, err := session.DomainSeparator()
+	if err != nil {
+		return nil, fmt.Errorf("Safe domainSeparator call errored with: '%v'", err)
+	}
+
+	msgHash := scMessageHash(challenge)
+	messageData := safeMessageData(domainSeparator, msgHash[:])
+
+	status := &SafeStatus{
+		Owners:      owners,
+		Threshold:   threshold.Uint64(),
+		MessageHash: common.BytesToHash(ethCrypto.Keccak256(messageData)),
+	}
+	status.Signatures = s.checkSignatures(&session, common.FromHex(signature), messageData, status.MessageHash)
+
+	isOwner := make(map[common.Address]bool, len(owners))
+	for _, owner := range owners {
+		isOwner[owner] = true
+	}
+
+	hasSigned := make(map[common.Address]bool)
+	for i := range status.Signatures {
+		sig := &status.Signatures[i]
+		sig.Valid = sig.Valid && isOwner[sig.Owner]
+		if sig.Valid && !hasSigned[sig.Owner] {
+			hasSigned[sig.Owner] = true
+			status.Signed = append(status.Signed, sig.Owner)
+		}
+	}
+	for _, owner := range owners {
+		if !hasSigned[owner] {
+			status.Missing = append(status.Missing, owner)
+		}
+	}
+
+	// a Safe with a zero threshold is not set up and can't sign anything
+	status.ThresholdMet = status.Threshold > 0 && uint64(len(status.Signed)) >= status.Threshold
+
+	result := &Result{
+		Authorized: status.ThresholdMet,
+		Address:    addr.Hex(),
+		Safe:       status,
+	}
+	if result.Authorized {
+		result.Method = MethodSafe
+	}
+	return result, nil
+}
+
+// checkSignatures splits and checks the 65 bytes owner signatures, following the Safe's checkSignatures logic.
+// The dynamic part of contract signatures is appended after the last 65 bytes signature.
+func (s *SafeVerifier) checkSignatures(session *ERCs.GnosisSafeCallerSession, sigBytes []byte, messageData []byte, hash common.Hash) []SafeSignature {
+	var sigs []SafeSignature
+
+	dynamicStart := len(sigBytes)
+	for i := 0; i+65 <= dynamicStart; i += 65 {
+		r, sv, v := sigBytes[i:i+32], sigBytes[i+32:i+64], sigBytes[i+64]
+
+		var sig SafeSignature
+		switch {
+		case v == 0:
+			sig.Type = SafeSignatureContract
+			sig.Owner = common.BytesToAddress(r)
+
+			offset := new(big.Int).SetBytes(sv)
+			var data []byte
+			data, sig.Err = safeContractSignature(sigBytes, offset, i+65)
+			if sig.Err == nil {
+				if int(offset.Int64()) < dynamicStart {
+					dynamicStart = int(offset.Int64())
+				}
+				sig.Valid, sig.Err = s.checkContractSignature(sig.Owner, messageData, data)
+			}
+		case v == 1:
+			sig.Type = SafeSignatureApprovedHash
+			sig.Owner = common.BytesToAddress(r)
+
+			var approved *big.Int
+			approved, sig.Err = session.ApprovedHashes(sig.Owner, hash)
+			sig.Valid = sig.Err == nil && approved.Sign() != 0
+		case v > 30:
+			sig.Type = SafeSignatureEthSign
+			sig.Owner, sig.Err = recoverSafeSigner(ethSignHash(hash), r, sv, v-4)
+			sig.Valid = sig.Err == nil
+		default:
+			sig.Type = SafeSignatureECDSA
+			sig.Owner, sig.Err = recoverSafeSigner(hash.Bytes(), r, sv, v)
+			sig.Valid = sig.Err == nil
+		}
+		sigs = append(sigs, sig)
+	}
+
+	return sigs
+}
+
+// checkContractSignature calls the legacy ERC-1271 isValidSignature(bytes,bytes) of an owner contract, like the Safe does.
+func (s *SafeVerifier) checkContractSignature(owner common.Address, messageData []byte, data []byte) (bool, error) {
+	_ERC1271LegacyCaller, err := ERCs.NewERC1271LegacyCaller(owner, s.cc)
+	if err != nil {
+		return false, err
+	}
+
+	magicValue, err := _ERC1271LegacyCaller.IsValidSignature(&bind.CallOpts{Pending: false, Context: s.ctx}, messageData, data)
+	if err != nil {
+		return false, err
+	}
+
+	return magicValue == _ERC1271LegacyMagicValue, nil
+}
+
+// safeContractSignature reads the length prefixed contract signature at offset, which must not overlap the static part.
+func safeContractSignature(sigBytes []byte, offset *big.Int, staticEnd int) ([]byte, error) {
+	if !offset.IsInt64() || offset.Int64() < int64(staticEnd) || offset.Int64()+32 > int64(len(sigBytes)) {
+		return nil, errors.New("invalid contract signature offset")
+	}
+	start := int(offset.Int64()) + 32
+
+	length := new(big.Int).SetBytes(sigBytes[start-32 : start])
+	if !length.IsInt64() || length.Int64() > int64(len(sigBytes)-start) {
+		return nil, errors.New("invalid contract signature length")
+	}
+
+	return sigBytes[start : start+int(length.Int64())], nil
+}
+
+func recoverSafeSigner(hash []byte, r, s []byte, v byte) (common.Address, error) {
+	sig := make([]byte, 65)
+	copy(sig[:32], r)
+	copy(sig[32:64], s)
+	sig[64] = v - 27 // Transform V from 27/28 to 0/1 according to the yellow paper
+
+	recoveredKey, err := ethCrypto.SigToPub(hash, sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return ethCrypto.PubkeyToAddress(*recoveredKey), nil
+}
+
+// safeMessageData is the EIP-712 encoding of a SafeMessage, whose keccak256 hash is what the owners sign.
+func safeMessageData(domainSeparator [32]byte, message []byte) []byte {
+	structHash := ethCrypto.Keccak256(_SafeMessageTypeHash.Bytes(), ethCrypto.Keccak256(message))
+
+	b := append([]byte{}, 25, 1)
+	b = append(b, domainSeparator[:]...)
+	b = append(b, structHash...)
+	return b
+}
+
+// ethSignHash is the eth_sign hash of a 32 bytes hash.
+func ethSignHash(hash common.Hash) []byte {
+	return ethCrypto.Keccak256([]byte("\x19Ethereum Signed Message:\n32"), hash.Bytes())
+}
@@ -0,0 +1,163 @@
+package dappauth
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestSafeVerifier(t *testing.T) {
+
+	keyA, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+	keyB, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+	keyC, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+	keyD, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+
+	ownerA := ethCrypto.PubkeyToAddress(keyA.PublicKey)
+	ownerB := ethCrypto.PubkeyToAddress(keyB.PublicKey)
+	ownerC := common.HexToAddress("0x00000000000000000000000000000000000000cc") // contract owner, signs with keyC
+	safeAddr := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	newMockSafe := func(threshold int64) *mockSafe {
+		return &mockSafe{
+			address:         safeAddr,
+			owners:          []common.Address{ownerA, ownerB, ownerC},
+			threshold:       threshold,
+			domainSeparator: common.HexToHash("0x0102"),
+			approvedHashes:  map[common.Address]common.Hash{},
+			contractOwners:  map[common.Address]*ecdsa.PublicKey{ownerC: &keyC.PublicKey},
+		}
+	}
+
+	msgHash := scMessageHash("foo")
+	messageData := safeMessageData(common.HexToHash("0x0102"), msgHash[:])
+	safeHash := ethCrypto.Keccak256(messageData)
+
+	t.Run("Safe should be authorized when the threshold of owners signed", func(t *testing.T) {
+		verifier := NewSafeVerifier(nil, newMockSafe(2))
+
+		sig := signSafeECDSA(safeHash, keyA, t) + signSafeECDSA(safeHash, keyB, t)
+		result, err := verifier.Verify("foo", sig, safeAddr.Hex())
+		checkError(err, t)
+
+		expectBool(result.Authorized, true, t)
+		expectString(string(result.Method), string(MethodSafe), t)
+		expectAddresses(result.Safe.Signed, []common.Address{ownerA, ownerB}, t)
+		expectAddresses(result.Safe.Missing, []common.Address{ownerC}, t)
+		expectBool(result.Safe.ThresholdMet, true, t)
+	})
+
+	t.Run("Safe should NOT be authorized when the threshold was not met", func(t *testing.T) {
+		verifier := NewSafeVerifier(nil, newMockSafe(2))
+
+		sig := signSafeECDSA(safeHash, keyA, t)
+		result, err := verifier.Verify("foo", sig, safeAddr.Hex())
+		checkError(err, t)
+
+		expectBool(result.Authorized, false, t)
+		expectAddresses(result.Safe.Signed, []common.Address{ownerA}, t)
+		expectAddresses(result.Safe.Missing, []common.Address{ownerB, ownerC}, t)
+	})
+
+	t.Run("Safe should not count signatures of non owners or duplicate owners", func(t *testing.T) {
+		verifier := NewSafeVerifier(nil, newMockSafe(2))
+
+		sig := signSafeECDSA(safeHash, keyA, t) + signSafeECDSA(safeHash, keyA, t) + signSafeECDSA(safeHash, keyD, t)
+		result, err := verifier.Verify("foo", sig, safeAddr.Hex())
+		checkError(err, t)
+
+		expectBool(result.Authorized, false, t)
+		expectAddresses(result.Safe.Signed, []common.Address{ownerA}, t)
+		expectBool(result.Safe.Signatures[2].Valid, false, t)
+		expectAddresses([]common.Address{result.Safe.Signatures[2].Owner}, []common.Address{ethCrypto.PubkeyToAddress(keyD.PublicKey)}, t)
+	})
+
+	t.Run("Safe should support eth_sign, approved hash and contract signatures", func(t *testing.T) {
+		mock := newMockSafe(3)
+		mock.approvedHashes[ownerB] = common.BytesToHash(safeHash)
+		verifier := NewSafeVerifier(nil, mock)
+
+		contractSig := signSafeECDSA(ethCrypto.Keccak256(messageData), keyC, t)
+		contractSigBytes, err := hex.DecodeString(contractSig)
+		checkError(err, t)
+
+		sig := signSafeEthSign(safeHash, keyA, t) +
+			hex.EncodeToString(safeStaticSignature(ownerB, big.NewInt(0), 1)) +
+			hex.EncodeToString(safeStaticSignature(ownerC, big.NewInt(65*3), 0)) +
+			hex.EncodeToString(safeDynamicSignature(contractSigBytes))
+		result, err := verifier.Verify("foo", sig, safeAddr.Hex())
+		checkError(err, t)
+
+		expectBool(result.Authorized, true, t)
+		expectString(string(result.Safe.Signatures[0].Type), string(SafeSignatureEthSign), t)
+		expectString(string(result.Safe.Signatures[1].Type), string(SafeSignatureApprovedHash), t)
+		expectString(string(result.Safe.Signatures[2].Type), string(SafeSignatureContract), t)
+		expectAddresses(result.Safe.Signed, []common.Address{ownerA, ownerB, ownerC}, t)
+	})
+
+	t.Run("Safe should reject contract signatures with an invalid offset", func(t *testing.T) {
+		verifier := NewSafeVerifier(nil, newMockSafe(1))
+
+		sig := hex.EncodeToString(safeStaticSignature(ownerC, big.NewInt(1000), 0))
+		result, err := verifier.Verify("foo", sig, safeAddr.Hex())
+		checkError(err, t)
+
+		expectBool(result.Authorized, false, t)
+		expectBool(result.Safe.Signatures[0].Err != nil, true, t)
+	})
+
+	t.Run("Safe verification should error when reading the owners errors", func(t *testing.T) {
+		mock := newMockSafe(1)
+		mock.errorGetOwners = true
+		verifier := NewSafeVerifier(nil, mock)
+
+		_, err := verifier.Verify("foo", signSafeECDSA(safeHash, keyA, t), safeAddr.Hex())
+		expectBool(err != nil, true, t)
+	})
+}
+
+func signSafeECDSA(hash []byte, key *ecdsa.PrivateKey, t *testing.T) string {
+	sig, err := ethCrypto.Sign(hash, key)
+	checkError(err, t)
+
+	sig[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
+	return hex.EncodeToString(sig)
+}
+
+func signSafeEthSign(hash []byte, key *ecdsa.PrivateKey, t *testing.T) string {
+	sig, err := ethCrypto.Sign(ethSignHash(common.BytesToHash(hash)), key)
+	checkError(err, t)
+
+	sig[64] += 31 // Safe marks eth_sign signatures by adding 4 to V
+	return hex.EncodeToString(sig)
+}
+
+func safeStaticSignature(owner common.Address, s *big.Int, v byte) []byte {
+	sig := append(common.LeftPadBytes(owner.Bytes(), 32), common.LeftPadBytes(s.Bytes(), 32)...)
+	return append(sig, v)
+}
+
+func safeDynamicSignature(data []byte) []byte {
+	return append(common.LeftPadBytes(big.NewInt(int64(len(data))).Bytes(), 32), data...)
+}
+
+func expectAddresses(actual, expected []common.Address, t *testing.T) {
+	if len(actual) != len(expected) {
+		t.Errorf("expected %v to be %v", actual, expected)
+		return
+	}
+	for i := range actual {
+		if actual[i] != expected[i] {
+			t.Errorf("expected %v to be %v", actual, expected)
+			return
+		}
+	}
+}
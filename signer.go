@@ -0,0 +1,85 @@
+package dappauth
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signer produces the signatures IsAuthorizedSigner accepts, for backend services, bots and tests that sign challenges.
+type Signer struct {
+	key *ecdsa.PrivateKey
+}
+
+// NewSigner creates a new Signer of the key.
+func NewSigner(key *ecdsa.PrivateKey) *Signer {
+	return &Signer{key: key}
+}
+
+// Address returns the address of the key.
+func (s *Signer) Address() common.Address {
+	return ethCrypto.PubkeyToAddress(s.key.PublicKey)
+}
+
+// SignPersonalMessage signs the challenge as an EOA, like personal_sign with wallets such as MetaMask (V is 27/28).
+func (s *Signer) SignPersonalMessage(challenge string) (string, error) {
+	return s.SignPrefixedMessage(EthereumMessagePrefix, challenge)
+}
+
+// SignPrefixedMessage signs the challenge as an EOA with the personal message prefix of another EVM family chain, see WithMessageProfile .
+func (s *Signer) SignPrefixedMessage(prefix, challenge string) (string, error) {
+	return s.sign(prefixedMessageHash(prefix, challenge))
+}
+
+// SignForContract signs the challenge as an owner of the contract wallet, ERC-1654 style: the key signs
+// the EIP-191 version 0 hash (0x1900 ++ wallet ++ hash) of the hash passed to isValidSignature in the hash mode.
+// Use HashModeRaw (or "") for the default Authenticator, and HashModeTypedData for EIP-712 typed data challenges.
+func (s *Signer) SignForContract(challenge string, wallet common.Address, mode HashMode) (string, error) {
+	if mode == "" {
+		mode = HashModeRaw
+	}
+	if mode == HashModeAuto {
+		return "", fmt.Errorf("a signature is for a single hash mode, not %q", mode)
+	}
+	hash, err := erc1271Hash(challenge, mode)
+	if err != nil {
+		return "", err
+	}
+	return s.sign(erc191MessageHash(hash[:], wallet))
+}
+
+// sign signs the hash, transforming V from 0/1 to 27/28.
+func (s *Signer) sign(hash []byte) (string, error) {
+	sig, err := ethCrypto.Sign(hash, s.key)
+	if err != nil {
+		return "", err
+	}
+	sig[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
+	return hexutil.Encode(sig), nil
+}
+
+// erc191MessageHash is the EIP-191 version 0 hash (0x1900 ++ address ++ msg) that contract wallet owners sign.
+func erc191MessageHash(msg []byte, address common.Address) []byte {
+
+	b := append([]byte{}, 25, 0)
+	b = append(b, address.Bytes()...)
+	b = append(b, msg...)
+
+	return ethCrypto.Keccak256(b)
+}
+
+// ConcatSignatures concatenates 65 byte signatures into the multi-signature of a multi sig contract wallet.
+func ConcatSignatures(signatures ...string) (string, error) {
+	var concatenated []byte
+	for _, signature := range signatures {
+		sig := common.FromHex(signature)
+		if len(sig) != 65 {
+			return "", fmt.Errorf("invalid signature length %d, expected 65", len(sig))
+		}
+		concatenated = append(concatenated, sig...)
+	}
+	return hexutil.Encode(concatenated), nil
+}
@@ -0,0 +1,100 @@
+package dappauth
+
+import (
+	"testing"
+
+	"github.com/dapperlabs/dappauth/dappauthtest"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestSigner(t *testing.T) {
+
+	keyA, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+	keyB, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+	keyC, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+
+	signerA := NewSigner(keyA)
+	signerB := NewSigner(keyB)
+	signerC := NewSigner(keyC)
+	wallet := signerA.Address()
+
+	mock := dappauthtest.NewWallet(wallet, 1, signerB.Address())
+
+	t.Run("Personal message signatures should match the EOA helper", func(t *testing.T) {
+		sig, err := signerA.SignPersonalMessage("foo")
+		checkError(err, t)
+		expectString(sig, "0x"+signEOAPersonalMessage("foo", keyA, t), t)
+
+		isAuthorizedSigner, err := NewAuthenticator(nil, mock).IsAuthorizedSigner("foo", sig, signerA.Address().Hex())
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, true, t)
+	})
+
+	t.Run("Prefixed message signatures should be authorized with the profile", func(t *testing.T) {
+		sig, err := signerA.SignPrefixedMessage(TronMessagePrefix, "foo")
+		checkError(err, t)
+
+		isAuthorizedSigner, err := NewAuthenticator(nil, mock, WithMessageProfile(TronProfile)).IsAuthorizedSigner("foo", sig, FormatTronAddress(signerA.Address()))
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, true, t)
+	})
+
+	t.Run("Contract signatures should be authorized in their hash mode", func(t *testing.T) {
+		for _, test := range []struct {
+			challenge string
+			mode      HashMode
+		}{
+			{"foo", ""},
+			{"0xffff", HashModeRaw},
+			{"foo", HashModePersonal},
+			{testTypedDataChallenge, HashModeTypedData},
+		} {
+			sig, err := signerB.SignForContract(test.challenge, wallet, test.mode)
+			checkError(err, t)
+			if test.mode == "" {
+				expectString(sig, "0x"+signERC1654PersonalMessage(test.challenge, keyB, wallet, t), t)
+			}
+
+			result, err := NewAuthenticator(nil, mock, WithHashMode(test.mode)).Verify(test.challenge, sig, wallet.Hex())
+			checkError(err, t)
+			expectBool(result.Authorized, true, t)
+			expectString(string(result.Method), string(MethodERC1271), t)
+		}
+
+		// signed by a key the wallet doesn't authorize
+		sig, err := signerC.SignForContract("foo", wallet, HashModeRaw)
+		checkError(err, t)
+		isAuthorizedSigner, err := NewAuthenticator(nil, mock).IsAuthorizedSigner("foo", sig, wallet.Hex())
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, false, t)
+
+		_, err = signerB.SignForContract("foo", wallet, HashModeAuto)
+		expectBool(err != nil, true, t)
+	})
+
+	t.Run("Concatenated signatures should be authorized by multi sig wallets", func(t *testing.T) {
+		sigB, err := signerB.SignForContract("foo", wallet, HashModeRaw)
+		checkError(err, t)
+		sigC, err := signerC.SignForContract("foo", wallet, HashModeRaw)
+		checkError(err, t)
+
+		multiSig, err := ConcatSignatures(sigB, sigC)
+		checkError(err, t)
+		expectString(multiSig, sigB+sigC[2:], t)
+
+		multiSigWallet := dappauthtest.NewWallet(wallet, 2, signerB.Address(), signerC.Address())
+		isAuthorizedSigner, err := NewAuthenticator(nil, multiSigWallet).IsAuthorizedSigner("foo", multiSig, wallet.Hex())
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, true, t)
+
+		isAuthorizedSigner, err = NewAuthenticator(nil, multiSigWallet).IsAuthorizedSigner("foo", sigB, wallet.Hex())
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, false, t)
+
+		_, err = ConcatSignatures(sigB, "0x1234")
+		expectBool(err != nil, true, t)
+	})
+}
@@ -0,0 +1,374 @@
+package dappauth
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/stateless"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie/utils"
+	"github.com/holiman/uint256"
+)
+
+// stateSource loads the accounts and storage a simulation touches.
+type stateSource interface {
+	account(addr common.Address) (*sourceAccount, error)
+	storage(addr common.Address, slot common.Hash) (common.Hash, error)
+}
+
+// sourceAccount is an account as loaded from a stateSource .
+type sourceAccount struct {
+	balance     *big.Int
+	nonce       uint64
+	code        []byte
+	storageRoot common.Hash // (proven accounts only)
+}
+
+type simAccount struct {
+	balance        *uint256.Int
+	nonce          uint64
+	code           []byte
+	codeHash       common.Hash
+	storage        map[common.Hash]common.Hash // Written slots
+	originStorage  map[common.Hash]common.Hash // Loaded slots
+	storageRoot    common.Hash                 // (proven accounts only)
+	fresh          bool                        // Created during the simulation, so has no loaded storage
+	newContract    bool                        // Deployed during the simulation, so can self-destruct after EIP-6780
+	selfDestructed bool
+}
+
+// simState implements vm.StateDB over a stateSource, loading accounts and storage lazily.
+// Writes are kept in memory and undone through a journal of undo functions.
+// As vm.StateDB can't return errors, the first load error is kept in err and must be checked after execution.
+type simState struct {
+	source    stateSource
+	accounts  map[common.Address]*simAccount
+	journal   []func()
+	refund    uint64
+	logs      []*types.Log
+	transient map[common.Address]map[common.Hash]common.Hash
+
+	accessAddrs map[common.Address]bool
+	accessSlots map[common.Address]map[common.Hash]bool
+
+	err error
+}
+
+func newSimState(source stateSource) *simState {
+	return &simState{
+		source:      source,
+		accounts:    make(map[common.Address]*simAccount),
+		transient:   make(map[common.Address]map[common.Hash]common.Hash),
+		accessAddrs: make(map[common.Address]bool),
+		accessSlots: make(map[common.Address]map[common.Hash]bool),
+	}
+}
+
+func (s *simState) getAccount(addr common.Address) *simAccount {
+	if account, ok := s.accounts[addr]; ok {
+		return account
+	}
+
+	account := &simAccount{
+		balance:       new(uint256.Int),
+		storage:       make(map[common.Hash]common.Hash),
+		originStorage: make(map[common.Hash]common.Hash),
+	}
+	loaded, err := s.source.account(addr)
+	if err != nil {
+		s.setError(err)
+	} else {
+		account.balance = uint256.MustFromBig(loaded.balance)
+		account.nonce = loaded.nonce
+		account.code = loaded.code
+		account.storageRoot = loaded.storageRoot
+	}
+	account.codeHash = ethCrypto.Keccak256Hash(account.code)
+
+	s.accounts[addr] = account
+	return account
+}
+
+func (s *simState) setError(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+func (s *simState) CreateAccount(addr common.Address) {
+	prev := s.getAccount(addr)
+	s.accounts[addr] = &simAccount{
+		balance:       prev.balance.Clone(),
+		codeHash:      ethCrypto.Keccak256Hash(nil),
+		storage:       make(map[common.Hash]common.Hash),
+		originStorage: make(map[common.Hash]common.Hash),
+		fresh:         true,
+	}
+	s.journal = append(s.journal, func() { s.accounts[addr] = prev })
+}
+
+func (s *simState) CreateContract(addr common.Address) {
+	account := s.getAccount(addr)
+	prev := account.newContract
+	account.newContract = true
+	s.journal = append(s.journal, func() { account.newContract = prev })
+}
+
+func (s *simState) SubBalance(addr common.Address, amount *uint256.Int, _ tracing.BalanceChangeReason) uint256.Int {
+	prev := *s.getAccount(addr).balance
+	s.setBalance(addr, new(uint256.Int).Sub(&prev, amount))
+	return prev
+}
+
+func (s *simState) AddBalance(addr common.Address, amount *uint256.Int, _ tracing.BalanceChangeReason) uint256.Int {
+	prev := *s.getAccount(addr).balance
+	s.setBalance(addr, new(uint256.Int).Add(&prev, amount))
+	return prev
+}
+
+func (s *simState) setBalance(addr common.Address, balance *uint256.Int) {
+	account := s.getAccount(addr)
+	prev := account.balance
+	account.balance = balance
+	s.journal = append(s.journal, func() { account.balance = prev })
+}
+
+func (s *simState) GetBalance(addr common.Address) *uint256.Int {
+	return s.getAccount(addr).balance.Clone()
+}
+
+func (s *simState) GetNonce(addr common.Address) uint64 {
+	return s.getAccount(addr).nonce
+}
+
+func (s *simState) SetNonce(addr common.Address, nonce uint64, _ tracing.NonceChangeReason) {
+	account := s.getAccount(addr)
+	prev := account.nonce
+	account.nonce = nonce
+	s.journal = append(s.journal, func() { account.nonce = prev })
+}
+
+func (s *simState) GetCodeHash(addr common.Address) common.Hash {
+	if !s.Exist(addr) {
+		return common.Hash{}
+	}
+	return s.getAccount(addr).codeHash
+}
+
+func (s *simState) GetCode(addr common.Address) []byte {
+	return s.getAccount(addr).code
+}
+
+func (s *simState) SetCode(addr common.Address, code []byte, _ tracing.CodeChangeReason) []byte {
+	account := s.getAccount(addr)
+	prevCode, prevHash := account.code, account.codeHash
+	account.code, account.codeHash = code, ethCrypto.Keccak256Hash(code)
+	s.journal = append(s.journal, func() { account.code, account.codeHash = prevCode, prevHash })
+	return prevCode
+}
+
+func (s *simState) GetCodeSize(addr common.Address) int {
+	return len(s.getAccount(addr).code)
+}
+
+func (s *simState) AddRefund(gas uint64) {
+	prev := s.refund
+	s.refund += gas
+	s.journal = append(s.journal, func() { s.refund = prev })
+}
+
+func (s *simState) SubRefund(gas uint64) {
+	prev := s.refund
+	if gas > s.refund {
+		gas = s.refund
+	}
+	s.refund -= gas
+	s.journal = append(s.journal, func() { s.refund = prev })
+}
+
+func (s *simState) GetRefund() uint64 {
+	return s.refund
+}
+
+func (s *simState) committedState(addr common.Address, slot common.Hash) common.Hash {
+	account := s.getAccount(addr)
+	if value, ok := account.originStorage[slot]; ok || account.fresh {
+		return value
+	}
+
+	value, err := s.source.storage(addr, slot)
+	if err != nil {
+		s.setError(err)
+	}
+	account.originStorage[slot] = value
+	return value
+}
+
+func (s *simState) GetState(addr common.Address, slot common.Hash) common.Hash {
+	if value, ok := s.getAccount(addr).storage[slot]; ok {
+		return value
+	}
+	return s.committedState(addr, slot)
+}
+
+func (s *simState) GetStateAndCommittedState(addr common.Address, slot common.Hash) (common.Hash, common.Hash) {
+	return s.GetState(addr, slot), s.committedState(addr, slot)
+}
+
+func (s *simState) SetState(addr common.Address, slot common.Hash, value common.Hash) common.Hash {
+	account := s.getAccount(addr)
+	prevValue := s.GetState(addr, slot)
+	prev, hadPrev := account.storage[slot]
+	account.storage[slot] = value
+	s.journal = append(s.journal, func() {
+		if hadPrev {
+			account.storage[slot] = prev
+		} else {
+			delete(account.storage, slot)
+		}
+	})
+	return prevValue
+}
+
+// GetStorageRoot is only used to detect address collisions, which is left to the root of proven accounts.
+func (s *simState) GetStorageRoot(addr common.Address) common.Hash {
+	return s.getAccount(addr).storageRoot
+}
+
+func (s *simState) GetTransientState(addr common.Address, slot common.Hash) common.Hash {
+	return s.transient[addr][slot]
+}
+
+func (s *simState) SetTransientState(addr common.Address, slot common.Hash, value common.Hash) {
+	prev := s.GetTransientState(addr, slot)
+	s.setTransientState(addr, slot, value)
+	s.journal = append(s.journal, func() { s.setTransientState(addr, slot, prev) })
+}
+
+func (s *simState) setTransientState(addr common.Address, slot common.Hash, value common.Hash) {
+	if s.transient[addr] == nil {
+		s.transient[addr] = make(map[common.Hash]common.Hash)
+	}
+	s.transient[addr][slot] = value
+}
+
+func (s *simState) SelfDestruct(addr common.Address) uint256.Int {
+	account := s.getAccount(addr)
+	prevSelfDestructed, prevBalance := account.selfDestructed, account.balance
+	if !s.Exist(addr) {
+		return *prevBalance
+	}
+	account.selfDestructed, account.balance = true, new(uint256.Int)
+	s.journal = append(s.journal, func() { account.selfDestructed, account.balance = prevSelfDestructed, prevBalance })
+	return *prevBalance
+}
+
+func (s *simState) SelfDestruct6780(addr common.Address) (uint256.Int, bool) {
+	account := s.getAccount(addr)
+	if !account.newContract {
+		return *account.balance, false
+	}
+	return s.SelfDestruct(addr), true
+}
+
+func (s *simState) HasSelfDestructed(addr common.Address) bool {
+	return s.getAccount(addr).selfDestructed
+}
+
+func (s *simState) Exist(addr common.Address) bool {
+	account := s.getAccount(addr)
+	return account.selfDestructed || account.fresh || !s.Empty(addr)
+}
+
+func (s *simState) Empty(addr common.Address) bool {
+	account := s.getAccount(addr)
+	return account.balance.IsZero() && account.nonce == 0 && len(account.code) == 0
+}
+
+// Prepare resets the transient storage, and fills the access list like a transaction of the fork would.
+func (s *simState) Prepare(rules params.Rules, sender, coinbase common.Address, dest *common.Address, precompiles []common.Address, txAccesses types.AccessList) {
+	s.transient = make(map[common.Address]map[common.Hash]common.Hash)
+	if !rules.IsBerlin {
+		return
+	}
+	s.AddAddressToAccessList(sender)
+	if rules.IsShanghai {
+		s.AddAddressToAccessList(coinbase)
+	}
+	if dest != nil {
+		s.AddAddressToAccessList(*dest)
+	}
+	for _, addr := range precompiles {
+		s.AddAddressToAccessList(addr)
+	}
+	for _, tuple := range txAccesses {
+		s.AddAddressToAccessList(tuple.Address)
+		for _, slot := range tuple.StorageKeys {
+			s.AddSlotToAccessList(tuple.Address, slot)
+		}
+	}
+}
+
+func (s *simState) AddressInAccessList(addr common.Address) bool {
+	return s.accessAddrs[addr]
+}
+
+func (s *simState) SlotInAccessList(addr common.Address, slot common.Hash) (bool, bool) {
+	return s.accessAddrs[addr], s.accessSlots[addr][slot]
+}
+
+func (s *simState) AddAddressToAccessList(addr common.Address) {
+	if s.accessAddrs[addr] {
+		return
+	}
+	s.accessAddrs[addr] = true
+	s.journal = append(s.journal, func() { delete(s.accessAddrs, addr) })
+}
+
+func (s *simState) AddSlotToAccessList(addr common.Address, slot common.Hash) {
+	s.AddAddressToAccessList(addr)
+	if s.accessSlots[addr] == nil {
+		s.accessSlots[addr] = make(map[common.Hash]bool)
+	}
+	if s.accessSlots[addr][slot] {
+		return
+	}
+	s.accessSlots[addr][slot] = true
+	s.journal = append(s.journal, func() { delete(s.accessSlots[addr], slot) })
+}
+
+func (s *simState) RevertToSnapshot(id int) {
+	for i := len(s.journal) - 1; i >= id; i-- {
+		s.journal[i]()
+	}
+	s.journal = s.journal[:id]
+}
+
+func (s *simState) Snapshot() int {
+	return len(s.journal)
+}
+
+func (s *simState) AddLog(log *types.Log) {
+	s.logs = append(s.logs, log)
+	s.journal = append(s.journal, func() { s.logs = s.logs[:len(s.logs)-1] })
+}
+
+func (s *simState) AddPreimage(common.Hash, []byte) {}
+
+func (s *simState) PointCache() *utils.PointCache {
+	return nil
+}
+
+func (s *simState) Witness() *stateless.Witness {
+	return nil
+}
+
+func (s *simState) AccessEvents() *state.AccessEvents {
+	return nil
+}
+
+func (s *simState) Finalise(bool) {}
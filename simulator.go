@@ -0,0 +1,586 @@
+package dappauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/dapperlabs/dappauth/ERCs"
+	"github.com/ethereum/go-ethereum"
+	ethAbi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// ErrUnsupportedEVMVersion is returned when a simulated call runs an opcode of a later fork than the chain config schedules at the simulated block,
+// e.g. PUSH0 with a ChainConfig without the Shanghai time, as the simulation couldn't reproduce the chain's result.
+var ErrUnsupportedEVMVersion = errors.New("unsupported EVM version")
+
+// laterForkOpcodes are the opcodes added by the forks after Berlin.
+var laterForkOpcodes = map[vm.OpCode]string{
+	vm.BASEFEE:     "BASEFEE (London)",
+	vm.PUSH0:       "PUSH0 (Shanghai)",
+	vm.BLOBHASH:    "BLOBHASH (Cancun)",
+	vm.BLOBBASEFEE: "BLOBBASEFEE (Cancun)",
+	vm.TLOAD:       "TLOAD (Cancun)",
+	vm.TSTORE:      "TSTORE (Cancun)",
+	vm.MCOPY:       "MCOPY (Cancun)",
+	vm.CLZ:         "CLZ (Osaka)",
+}
+
+// StateReader is the chain access a Simulator needs, implemented by ethclient.Client .
+type StateReader interface {
+	ethereum.ChainStateReader
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// StateOverride replaces parts of an account's state in simulations.
+type StateOverride struct {
+	Code    []byte                      // Replaces the code (nil = keep)
+	Balance *big.Int                    // Replaces the balance (nil = keep)
+	Nonce   *uint64                     // Replaces the nonce (nil = keep)
+	Storage map[common.Hash]common.Hash // Replaces the listed slots, others are kept
+}
+
+// SimulatorConfig configures a Simulator .
+type SimulatorConfig struct {
+	ChainConfig *params.ChainConfig // EVM rules, picked by the number and time of the block (nil = params.MainnetChainConfig)
+	From        common.Address      // Caller of calls that don't set one
+	GasLimit    uint64              // Gas of calls that don't set one (0 = block gas limit)
+	Overrides   map[common.Address]StateOverride
+	HashMode    HashMode // ERC-1271 hash mode of Verify (empty = HashModeRaw)
+	Trace       bool     // Record an opcode level trace of each call
+
+	// With a ProofReader and a trusted header or state root, all state is checked against Merkle proofs instead of trusting the StateReader.
+	ProofReader      ProofReader
+	TrustedHeader    *types.Header // Header of the only block simulated, whose block context (time, number, coinbase, ...) is also trusted
+	TrustedStateRoot common.Hash   // State root of TrustedBlock, if there is no TrustedHeader. The rest of its header comes from the StateReader, so simulations aren't Proven
+	TrustedBlock     *big.Int      // Block of TrustedStateRoot (nil = latest)
+}
+
+func (c *SimulatorConfig) trusted() bool {
+	return c.ProofReader != nil && (c.TrustedHeader != nil || c.TrustedStateRoot != (common.Hash{}))
+}
+
+// Simulation is the outcome of a call run in the simulated EVM.
+type Simulation struct {
+	BlockNumber uint64
+	From        common.Address
+	To          common.Address
+	Input       []byte
+	ReturnData  []byte
+	GasLimit    uint64
+	GasUsed     uint64             // Execution gas, without the intrinsic gas of a transaction
+	Err         error              // The EVM error, like vm.ErrExecutionReverted or vm.ErrOutOfGas (nil on success)
+	Trace       []logger.StructLog // (SimulatorConfig.Trace only)
+	Proven      bool               // The state was checked against Merkle proofs of the TrustedHeader, whose block context was simulated, and no override was read
+}
+
+// Simulator runs calls in go-ethereum's in-process EVM, over state fetched lazily from a StateReader and cached per block.
+// It implements bind.ContractCaller, so it can also back an Authenticator .
+type Simulator struct {
+	sr     StateReader
+	ctx    context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+	config SimulatorConfig
+
+	mu    sync.Mutex
+	cache *blockCache // State fetched at the last simulated block
+}
+
+// blockCache holds the state fetched at a single block.
+type blockCache struct {
+	header   *types.Header
+	accounts map[common.Address]*sourceAccount
+	storage  map[common.Address]map[common.Hash]common.Hash
+	hashes   map[uint64]common.Hash
+}
+
+// NewSimulator creates a new Simulator .
+func NewSimulator(ctx context.Context, sr StateReader, config SimulatorConfig) *Simulator {
+	// go-ethereum's clients don't accept a nil context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if config.ChainConfig == nil {
+		config.ChainConfig = params.MainnetChainConfig
+	}
+	return &Simulator{
+		ctx:    ctx,
+		sr:     sr,
+		config: config,
+	}
+}
+
+// Verify runs isValidSignature of the wallet at addrHex in the simulated EVM, trying the hash modes of the config in order.
+// The Simulation of the last call is attached to the result.
+func (s *Simulator) Verify(challenge, signature, addrHex string) (*Result, error) {
+	addr := common.HexToAddress(addrHex)
+	origSigBytes := common.FromHex(signature)
+
+	abi, err := ethAbi.JSON(strings.NewReader(ERCs.ERC1271ABI))
+	if err != nil {
+		return nil, err
+	}
+
+	// all hash modes are simulated on the same block
+	cache, err := s.blockCache(s.ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Address: addr.Hex()}
+	for _, hashMode := range s.config.HashMode.hashModes() {
+		hash, err := erc1271Hash(challenge, hashMode)
+		if err != nil {
+			continue
+		}
+		input, err := abi.Pack("isValidSignature", hash, origSigBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Simulation, err = s.simulate(s.ctx, cache, ethereum.CallMsg{To: &addr, Data: input})
+		if err != nil {
+			return nil, err
+		}
+		if result.Simulation.Err != nil {
+			continue
+		}
+
+		returnShape, err := matchMagicValue(abi, result.Simulation.ReturnData, _ERC1271MagicValue, ReturnDecodingStandard)
+		if err == nil && returnShape != "" {
+			result.Authorized, result.Method, result.HashMode, result.ReturnShape = true, MethodERC1271, hashMode, returnShape
+			break
+		}
+	}
+
+	if result.Simulation == nil {
+		return nil, fmt.Errorf("challenge can't be hashed in hash mode %q", s.config.HashMode)
+	}
+	return result, nil
+}
+
+// Simulate runs the call at blockNumber (nil = latest).
+func (s *Simulator) Simulate(call ethereum.CallMsg, blockNumber *big.Int) (*Simulation, error) {
+	cache, err := s.blockCache(s.ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return s.simulate(s.ctx, cache, call)
+}
+
+// CodeAt implements bind.ContractCaller, returning the code with overrides applied.
+func (s *Simulator) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	cache, err := s.blockCache(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	account, err := s.source(ctx, cache).account(contract)
+	if err != nil {
+		return nil, err
+	}
+	return account.code, nil
+}
+
+// StorageAt returns the storage slot with overrides applied, like ethclient.Client .
+func (s *Simulator) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	cache, err := s.blockCache(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	value, err := s.source(ctx, cache).storage(account, key)
+	if err != nil {
+		return nil, err
+	}
+	return value.Bytes(), nil
+}
+
+// CallContract implements bind.ContractCaller, returning the EVM error of failed calls.
+func (s *Simulator) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	cache, err := s.blockCache(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	simulation, err := s.simulate(ctx, cache, call)
+	if err != nil {
+		return nil, err
+	}
+	if simulation.Err != nil {
+		return simulation.ReturnData, &simulationError{err: simulation.Err, data: simulation.ReturnData}
+	}
+	return simulation.ReturnData, nil
+}
+
+// simulationError is the EVM error of a simulated call, carrying the revert data like the JSON-RPC errors of eth_call.
+type simulationError struct {
+	err  error
+	data []byte
+}
+
+func (e *simulationError) Error() string {
+	return e.err.Error()
+}
+
+func (e *simulationError) Unwrap() error {
+	return e.err
+}
+
+// ErrorData implements rpc.DataError .
+func (e *simulationError) ErrorData() interface{} {
+	return hexutil.Encode(e.data)
+}
+
+func (s *Simulator) simulate(ctx context.Context, cache *blockCache, call ethereum.CallMsg) (*Simulation, error) {
+	if call.To == nil {
+		return nil, fmt.Errorf("contract creation is not supported")
+	}
+	header := cache.header
+	source := s.source(ctx, cache)
+
+	simulation := &Simulation{
+		BlockNumber: header.Number.Uint64(),
+		From:        call.From,
+		To:          *call.To,
+		Input:       call.Data,
+		GasLimit:    call.Gas,
+	}
+	if simulation.From == (common.Address{}) {
+		simulation.From = s.config.From
+	}
+	if simulation.GasLimit == 0 {
+		simulation.GasLimit = s.config.GasLimit
+	}
+	if simulation.GasLimit == 0 {
+		simulation.GasLimit = header.GasLimit
+	}
+	value := new(uint256.Int)
+	if call.Value != nil {
+		value = uint256.MustFromBig(call.Value)
+	}
+	gasPrice := call.GasPrice
+	if gasPrice == nil {
+		gasPrice = new(big.Int)
+	}
+
+	blockCtx := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		GetHash:     source.blockHash,
+		Coinbase:    header.Coinbase,
+		GasLimit:    header.GasLimit,
+		BlockNumber: new(big.Int).Set(header.Number),
+		Time:        header.Time,
+		Difficulty:  header.Difficulty,
+		BaseFee:     header.BaseFee,
+	}
+	// like eth_call, calls without a gas price run with a zero base fee
+	if gasPrice.Sign() == 0 && header.BaseFee != nil {
+		blockCtx.BaseFee = new(big.Int)
+	}
+	if header.ExcessBlobGas != nil {
+		blockCtx.BlobBaseFee = eip4844.CalcBlobFee(s.config.ChainConfig, header)
+	}
+	// the EVM picks the fork rules from the number, time and, after the merge, the randomness of the block
+	if header.Difficulty.Sign() == 0 {
+		blockCtx.Random = &header.MixDigest
+	}
+
+	state := newSimState(source)
+
+	// the fork tracer runs on every call, as nested calls swallow the errors of opcodes the EVM doesn't support
+	tracer := &forkTracer{state: state, trace: s.config.Trace}
+	evm := vm.NewEVM(blockCtx, state, s.config.ChainConfig, vm.Config{Tracer: tracer.hooks()})
+	evm.SetTxContext(vm.TxContext{Origin: simulation.From, GasPrice: gasPrice})
+	rules := evm.ChainConfig().Rules(blockCtx.BlockNumber, blockCtx.Random != nil, blockCtx.Time)
+	state.Prepare(rules, simulation.From, header.Coinbase, call.To, vm.ActivePrecompiles(rules), call.AccessList)
+
+	ret, leftOverGas, err := evm.Call(simulation.From, simulation.To, call.Data, simulation.GasLimit, value)
+	if state.err != nil {
+		return nil, state.err
+	}
+	if tracer.unsupported != "" {
+		return nil, fmt.Errorf("Simulation of %s hit opcode %s: %w", simulation.To.Hex(), tracer.unsupported, ErrUnsupportedEVMVersion)
+	}
+
+	simulation.ReturnData = ret
+	// with only a trusted state root, the block context (and the fork it picks) comes from the untrusted header
+	simulation.Proven = s.config.trusted() && s.config.TrustedHeader != nil && !source.overridden
+	simulation.GasUsed = simulation.GasLimit - leftOverGas
+	simulation.Err = err
+	simulation.Trace = tracer.logs
+	return simulation, nil
+}
+
+// forkTracer records the first opcode of a later fork than the simulated EVM runs, and the steps of the call if trace is set.
+type forkTracer struct {
+	state       *simState
+	trace       bool
+	logs        []logger.StructLog
+	unsupported string
+}
+
+func (t *forkTracer) hooks() *tracing.Hooks {
+	return &tracing.Hooks{OnOpcode: t.onOpcode, OnFault: t.onFault}
+}
+
+func (t *forkTracer) onOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	t.checkFork(op, err)
+	if t.trace {
+		t.logs = append(t.logs, logger.StructLog{
+			Pc:            pc,
+			Op:            vm.OpCode(op),
+			Gas:           gas,
+			GasCost:       cost,
+			Stack:         append([]uint256.Int(nil), scope.StackData()...),
+			Depth:         depth,
+			RefundCounter: t.state.GetRefund(),
+			Err:           err,
+		})
+	}
+}
+
+// onFault gets the errors of opcodes that failed after their step was traced, like undefined opcodes.
+func (t *forkTracer) onFault(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, depth int, err error) {
+	t.checkFork(op, err)
+	if t.trace && len(t.logs) > 0 {
+		t.logs[len(t.logs)-1].Err = err
+	}
+}
+
+func (t *forkTracer) checkFork(op byte, err error) {
+	var invalid *vm.ErrInvalidOpCode
+	if t.unsupported == "" && errors.As(err, &invalid) {
+		t.unsupported = laterForkOpcodes[vm.OpCode(op)]
+	}
+}
+
+// blockCache returns the cache of the block, replacing the cache of the previous block if it changed.
+func (s *Simulator) blockCache(ctx context.Context, blockNumber *big.Int) (*blockCache, error) {
+	s.mu.Lock()
+	cache := s.cache
+	s.mu.Unlock()
+
+	if cache != nil && (blockNumber != nil || s.config.trusted()) && (blockNumber == nil || cache.header.Number.Cmp(blockNumber) == 0) {
+		return cache, nil
+	}
+
+	header, err := s.header(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cache == nil || s.cache.header.Hash() != header.Hash() {
+		s.cache = &blockCache{
+			header:   header,
+			accounts: make(map[common.Address]*sourceAccount),
+			storage:  make(map[common.Address]map[common.Hash]common.Hash),
+			hashes:   map[uint64]common.Hash{header.Number.Uint64(): header.Hash()},
+		}
+	}
+	return s.cache, nil
+}
+
+// header returns the header of the block, which in trusted mode can only be the trusted block.
+func (s *Simulator) header(ctx context.Context, blockNumber *big.Int) (*types.Header, error) {
+	if !s.config.trusted() {
+		header, err := s.sr.HeaderByNumber(ctx, blockNumber)
+		if err != nil {
+			return nil, fmt.Errorf("Simulation header lookup errored with: '%v'", err)
+		}
+		return header, nil
+	}
+
+	header := s.config.TrustedHeader
+	if header == nil {
+		var err error
+		header, err = s.sr.HeaderByNumber(ctx, s.config.TrustedBlock)
+		if err != nil {
+			return nil, fmt.Errorf("Simulation header lookup errored with: '%v'", err)
+		}
+		if header.Root != s.config.TrustedStateRoot {
+			return nil, fmt.Errorf("Simulation header %v doesn't match the trusted state root", header.Number)
+		}
+	}
+	if blockNumber != nil && blockNumber.Cmp(header.Number) != 0 {
+		return nil, fmt.Errorf("Simulation of block %v isn't supported, only the trusted block %v is", blockNumber, header.Number)
+	}
+	return header, nil
+}
+
+func (s *Simulator) source(ctx context.Context, cache *blockCache) *rpcSource {
+	return &rpcSource{sim: s, ctx: ctx, cache: cache}
+}
+
+// rpcSource loads state from the StateReader through the block cache, and applies the overrides on top.
+type rpcSource struct {
+	sim        *Simulator
+	ctx        context.Context
+	cache      *blockCache
+	overridden bool // An override replaced state read through the source
+}
+
+func (r *rpcSource) account(addr common.Address) (*sourceAccount, error) {
+	r.sim.mu.Lock()
+	cached, ok := r.cache.accounts[addr]
+	r.sim.mu.Unlock()
+
+	if !ok && r.sim.config.trusted() {
+		var err error
+		cached, err = r.fetchProvenAccount(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		r.sim.mu.Lock()
+		r.cache.accounts[addr] = cached
+		r.sim.mu.Unlock()
+	} else if !ok {
+		number := r.cache.header.Number
+		balance, err := r.sim.sr.BalanceAt(r.ctx, addr, number)
+		if err != nil {
+			return nil, fmt.Errorf("Simulation balance lookup of %s errored with: '%v'", addr.Hex(), err)
+		}
+		nonce, err := r.sim.sr.NonceAt(r.ctx, addr, number)
+		if err != nil {
+			return nil, fmt.Errorf("Simulation nonce lookup of %s errored with: '%v'", addr.Hex(), err)
+		}
+		code, err := r.sim.sr.CodeAt(r.ctx, addr, number)
+		if err != nil {
+			return nil, fmt.Errorf("Simulation code lookup of %s errored with: '%v'", addr.Hex(), err)
+		}
+		cached = &sourceAccount{balance: balance, nonce: nonce, code: code}
+
+		r.sim.mu.Lock()
+		r.cache.accounts[addr] = cached
+		r.sim.mu.Unlock()
+	}
+
+	account := *cached
+	if override, ok := r.sim.config.Overrides[addr]; ok {
+		if override.Code != nil {
+			account.code = override.Code
+			r.overridden = true
+		}
+		if override.Balance != nil {
+			account.balance = override.Balance
+			r.overridden = true
+		}
+		if override.Nonce != nil {
+			account.nonce = *override.Nonce
+			r.overridden = true
+		}
+	}
+	return &account, nil
+}
+
+func (r *rpcSource) storage(addr common.Address, slot common.Hash) (common.Hash, error) {
+	if value, ok := r.sim.config.Overrides[addr].Storage[slot]; ok {
+		r.overridden = true
+		return value, nil
+	}
+
+	r.sim.mu.Lock()
+	value, ok := r.cache.storage[addr][slot]
+	r.sim.mu.Unlock()
+	if ok {
+		return value, nil
+	}
+
+	if r.sim.config.trusted() {
+		var err error
+		value, err = r.fetchProvenStorage(addr, slot)
+		if err != nil {
+			return common.Hash{}, err
+		}
+	} else {
+		b, err := r.sim.sr.StorageAt(r.ctx, addr, slot, r.cache.header.Number)
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("Simulation storage lookup of %s errored with: '%v'", addr.Hex(), err)
+		}
+		value = common.BytesToHash(b)
+	}
+
+	r.sim.mu.Lock()
+	if r.cache.storage[addr] == nil {
+		r.cache.storage[addr] = make(map[common.Hash]common.Hash)
+	}
+	r.cache.storage[addr][slot] = value
+	r.sim.mu.Unlock()
+	return value, nil
+}
+
+// blockHash implements vm.GetHashFunc, fetching the hashes of previous blocks.
+func (r *rpcSource) blockHash(number uint64) common.Hash {
+	r.sim.mu.Lock()
+	hash, ok := r.cache.hashes[number]
+	r.sim.mu.Unlock()
+	if ok {
+		return hash
+	}
+	if r.sim.config.trusted() {
+		return r.provenBlockHash(number)
+	}
+
+	header, err := r.sim.sr.HeaderByNumber(r.ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return common.Hash{}
+	}
+
+	r.sim.mu.Lock()
+	r.cache.hashes[number] = header.Hash()
+	r.sim.mu.Unlock()
+	return header.Hash()
+}
+
+// provenBlockHash walks the parent hashes back from the trusted header, checking each fetched header against the hash of its child.
+func (r *rpcSource) provenBlockHash(number uint64) common.Hash {
+	current := r.cache.header.Number.Uint64()
+	if number >= current {
+		return common.Hash{}
+	}
+
+	r.sim.mu.Lock()
+	r.cache.hashes[current-1] = r.cache.header.ParentHash
+	lowest := current - 1
+	for lowest > number {
+		if _, ok := r.cache.hashes[lowest-1]; !ok {
+			break
+		}
+		lowest--
+	}
+	r.sim.mu.Unlock()
+
+	for ; lowest > number; lowest-- {
+		header, err := r.sim.sr.HeaderByNumber(r.ctx, new(big.Int).SetUint64(lowest))
+		if err != nil {
+			return common.Hash{}
+		}
+
+		r.sim.mu.Lock()
+		valid := header.Hash() == r.cache.hashes[lowest]
+		if valid {
+			r.cache.hashes[lowest-1] = header.ParentHash
+		}
+		r.sim.mu.Unlock()
+		if !valid {
+			return common.Hash{}
+		}
+	}
+
+	r.sim.mu.Lock()
+	defer r.sim.mu.Unlock()
+	return r.cache.hashes[number]
+}
@@ -0,0 +1,192 @@
+package dappauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// returns the ERC-1271 magic value if the hash passed to isValidSignature is stored in slot 0
+const approvedHashWalletCode = "0x60043560005414600f5760206000f35b631626ba7e60e01b60005260206000f3"
+
+var dummySignature = "0x" + strings.Repeat("00", 65)
+
+// mockStateReader serves a fixed state at a single block and counts the lookups.
+type mockStateReader struct {
+	header  *types.Header
+	code    map[common.Address][]byte
+	storage map[common.Address]map[common.Hash]common.Hash
+	lookups int
+}
+
+func (m *mockStateReader) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if number != nil && number.Cmp(m.header.Number) != 0 {
+		return nil, fmt.Errorf("Unknown block %v", number)
+	}
+	return m.header, nil
+}
+
+func (m *mockStateReader) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	m.lookups++
+	return new(big.Int), nil
+}
+
+func (m *mockStateReader) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	m.lookups++
+	return 0, nil
+}
+
+func (m *mockStateReader) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	m.lookups++
+	return m.code[account], nil
+}
+
+func (m *mockStateReader) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	m.lookups++
+	value := m.storage[account][key]
+	return value[:], nil
+}
+
+func TestSimulator(t *testing.T) {
+
+	wallet := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	approvedHash := scMessageHash("foo")
+
+	newReader := func() *mockStateReader {
+		return &mockStateReader{
+			header:  &types.Header{Number: big.NewInt(12244000), GasLimit: 15000000, Difficulty: new(big.Int)},
+			code:    map[common.Address][]byte{wallet: common.FromHex(approvedHashWalletCode)},
+			storage: map[common.Address]map[common.Hash]common.Hash{wallet: {{}: approvedHash}},
+		}
+	}
+
+	t.Run("Simulated wallets should authorize signatures they validate", func(t *testing.T) {
+		simulator := NewSimulator(nil, newReader(), SimulatorConfig{Trace: true})
+
+		result, err := simulator.Verify("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+
+		expectBool(result.Authorized, true, t)
+		expectString(string(result.Method), string(MethodERC1271), t)
+		expectBool(result.Simulation.Err == nil, true, t)
+		expectBool(result.Simulation.GasUsed > 0, true, t)
+		expectBool(len(result.Simulation.Trace) > 0, true, t)
+		expectString(result.Simulation.Trace[len(result.Simulation.Trace)-1].Op.String(), vm.RETURN.String(), t)
+	})
+
+	t.Run("Simulated wallets should NOT authorize signatures they don't validate", func(t *testing.T) {
+		simulator := NewSimulator(nil, newReader(), SimulatorConfig{})
+
+		result, err := simulator.Verify("bar", dummySignature, wallet.Hex())
+		checkError(err, t)
+
+		expectBool(result.Authorized, false, t)
+		expectBool(result.Simulation.Trace == nil, true, t)
+	})
+
+	t.Run("State should be cached per block", func(t *testing.T) {
+		reader := newReader()
+		simulator := NewSimulator(nil, reader, SimulatorConfig{})
+
+		_, err := simulator.Verify("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		lookups := reader.lookups
+
+		_, err = simulator.Verify("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(lookups > 0 && reader.lookups == lookups, true, t)
+	})
+
+	t.Run("Overrides should replace the fetched state", func(t *testing.T) {
+		otherHash := scMessageHash("bar")
+		simulator := NewSimulator(nil, newReader(), SimulatorConfig{
+			Overrides: map[common.Address]StateOverride{
+				wallet: {Storage: map[common.Hash]common.Hash{{}: otherHash}},
+			},
+		})
+
+		result, err := simulator.Verify("bar", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+
+		result, err = simulator.Verify("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, false, t)
+	})
+
+	t.Run("Calls should run out of gas above the gas limit", func(t *testing.T) {
+		simulator := NewSimulator(nil, newReader(), SimulatorConfig{GasLimit: 100})
+
+		result, err := simulator.Verify("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+
+		expectBool(result.Authorized, false, t)
+		expectBool(result.Simulation.Err == vm.ErrOutOfGas, true, t)
+		expectBool(result.Simulation.GasUsed == 100, true, t)
+	})
+
+	t.Run("Simulator should back an Authenticator", func(t *testing.T) {
+		simulator := NewSimulator(nil, newReader(), SimulatorConfig{})
+		authenticator := NewAuthenticator(nil, simulator)
+
+		isAuthorizedSigner, err := authenticator.IsAuthorizedSigner("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, true, t)
+	})
+
+	// PUSH0 PUSH0 RETURN, called through a proxy that swallows the error of the nested call
+	implementation := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	newPush0Reader := func(number int64, time uint64) *mockStateReader {
+		proxyCode := "0x6000600060006000" + "73" + strings.TrimPrefix(strings.ToLower(implementation.Hex()), "0x") + "5af4" + "00"
+		reader := newReader()
+		reader.header.Number, reader.header.Time = big.NewInt(number), time
+		reader.code[implementation] = common.FromHex("0x5f5ff3")
+		reader.code[wallet] = common.FromHex(proxyCode)
+		return reader
+	}
+
+	t.Run("Opcodes of later forks should fail with an unsupported EVM version error", func(t *testing.T) {
+		simulator := NewSimulator(nil, newPush0Reader(12244000, 1618481223), SimulatorConfig{})
+
+		_, err := simulator.Verify("foo", dummySignature, wallet.Hex())
+		expectBool(errors.Is(err, ErrUnsupportedEVMVersion), true, t)
+		expectBool(strings.Contains(err.Error(), "PUSH0"), true, t)
+	})
+
+	t.Run("Simulations should run the fork of the block's number and time", func(t *testing.T) {
+		// the first Shanghai block
+		simulator := NewSimulator(nil, newPush0Reader(17034870, 1681338455), SimulatorConfig{})
+
+		result, err := simulator.Verify("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(result.Simulation.Err == nil, true, t)
+		expectBool(result.Authorized, false, t)
+
+		// TSTORE TLOAD of slot 0 in the first Cancun block
+		reader := newReader()
+		reader.header.Number, reader.header.Time = big.NewInt(19426587), 1710338135
+		reader.code[wallet] = common.FromHex("0x631626ba7e60e01b60005d60005c60005260206000f3")
+		simulator = NewSimulator(nil, reader, SimulatorConfig{})
+
+		result, err = simulator.Verify("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+
+		// the same block before Cancun in a chain config without its time
+		config := *params.MainnetChainConfig
+		config.CancunTime, config.PragueTime, config.OsakaTime, config.BPO1Time, config.BPO2Time = nil, nil, nil, nil, nil
+		simulator = NewSimulator(nil, reader, SimulatorConfig{ChainConfig: &config})
+
+		_, err = simulator.Verify("foo", dummySignature, wallet.Hex())
+		expectBool(errors.Is(err, ErrUnsupportedEVMVersion), true, t)
+		expectBool(strings.Contains(err.Error(), "TSTORE"), true, t)
+	})
+}
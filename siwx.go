@@ -0,0 +1,238 @@
+package dappauth
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const siwxHeaderInfix = " wants you to sign in with your "
+
+// SIWxMessage is a parsed CAIP-122 Sign-In With X message, the chain agnostic form of EIP-4361 (SIWE) and SIWS.
+type SIWxMessage struct {
+	Domain         string
+	ChainName      string // The chain of the header, e.g. "Ethereum" in "... sign in with your Ethereum account:"
+	Address        string
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        string // The CAIP-2 reference of the chain, e.g. "1" for eip155:1
+	Nonce          string
+	IssuedAt       string
+	ExpirationTime string
+	NotBefore      string
+	RequestID      string
+	Resources      []string
+}
+
+// SIWxNamespace verifies the accounts of a CAIP-2 namespace, whose messages name the chain ChainName.
+// Messages for chains other than ChainIDs are rejected, as the Verifier only checks the chain it is connected to.
+type SIWxNamespace struct {
+	Namespace string   // e.g. "eip155", "solana", "cosmos"
+	ChainName string   // e.g. "Ethereum", "Solana", "Cosmos"
+	ChainIDs  []string // Accepted CAIP-2 references, e.g. "1" for eip155:1 or "mainnet" for Solana
+	Verifier  Verifier // Verifies the signature of the message by the address, e.g. an Authenticator for eip155
+}
+
+// SIWx verifies CAIP-122 Sign-In With X messages of several namespaces, checking the domain, nonce and time bounds the same way for all of them.
+type SIWx struct {
+	domain     string
+	namespaces []SIWxNamespace
+	now        func() time.Time // Clock of the time checks
+}
+
+// NewSIWx creates a new SIWx . If domain is set, messages must be for it.
+func NewSIWx(domain string, namespaces ...SIWxNamespace) *SIWx {
+	return &SIWx{
+		domain:     domain,
+		namespaces: namespaces,
+		now:        time.Now,
+	}
+}
+
+// SignIn checks the message is for the domain, an accepted chain of its namespace, has the issued nonce and is valid now,
+// and that the verifier of its namespace authorizes the signature by its address.
+// The result has the parsed message and the CAIP-10 account of the address.
+func (s *SIWx) SignIn(message, signature, nonce string) (*Result, error) {
+	if nonce == "" {
+		return nil, fmt.Errorf("the nonce issued for the SIWx message is required")
+	}
+	siwx, ok := ParseSIWxMessage(message)
+	if !ok {
+		return nil, fmt.Errorf("message isn't a SIWx message")
+	}
+
+	var namespace *SIWxNamespace
+	for i := range s.namespaces {
+		if s.namespaces[i].ChainName == siwx.ChainName {
+			namespace = &s.namespaces[i]
+			break
+		}
+	}
+	if namespace == nil {
+		return nil, fmt.Errorf("SIWx message is for unsupported chain %q", siwx.ChainName)
+	}
+
+	if err := s.check(siwx, nonce); err != nil {
+		return nil, err
+	}
+	if !namespace.acceptsChain(siwx.ChainID) {
+		return nil, fmt.Errorf("SIWx message is for unsupported %s chain ID %q", namespace.Namespace, siwx.ChainID)
+	}
+
+	result, err := namespace.Verifier.Verify(message, signature, siwx.Address)
+	if err != nil {
+		return nil, err
+	}
+	result.SIWx = siwx
+	result.Account = fmt.Sprintf("%s:%s:%s", namespace.Namespace, siwx.ChainID, siwx.Address)
+	return result, nil
+}
+
+// check checks the message is for the domain, has the nonce and is valid now.
+func (s *SIWx) check(siwx *SIWxMessage, nonce string) error {
+	if s.domain != "" && siwx.Domain != s.domain {
+		return fmt.Errorf("SIWx message is for domain %s", siwx.Domain)
+	}
+	if siwx.Nonce == "" {
+		return fmt.Errorf("SIWx message has no nonce")
+	}
+	if siwx.Nonce != nonce {
+		return fmt.Errorf("SIWx message has nonce %s", siwx.Nonce)
+	}
+	if siwx.ChainID == "" {
+		return fmt.Errorf("SIWx message has no chain ID")
+	}
+	if siwx.IssuedAt != "" {
+		if _, err := time.Parse(time.RFC3339, siwx.IssuedAt); err != nil {
+			return fmt.Errorf("invalid SIWx issued at time: %v", err)
+		}
+	}
+	return checkSignInTimes("SIWx", s.now(), siwx.ExpirationTime, siwx.NotBefore)
+}
+
+// acceptsChain checks the chain ID is one of the namespace.
+func (n *SIWxNamespace) acceptsChain(chainID string) bool {
+	for _, accepted := range n.ChainIDs {
+		if chainID == accepted {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSignInTimes checks the RFC 3339 expiration and not before times (if set) of a sign in message around now.
+func checkSignInTimes(kind string, now time.Time, expirationTime, notBefore string) error {
+	if expirationTime != "" {
+		expiration, err := time.Parse(time.RFC3339, expirationTime)
+		if err != nil {
+			return fmt.Errorf("invalid %s expiration time: %v", kind, err)
+		}
+		if !now.Before(expiration) {
+			return fmt.Errorf("%s message expired at %s", kind, expirationTime)
+		}
+	}
+	if notBefore != "" {
+		notBeforeTime, err := time.Parse(time.RFC3339, notBefore)
+		if err != nil {
+			return fmt.Errorf("invalid %s not before time: %v", kind, err)
+		}
+		if now.Before(notBeforeTime) {
+			return fmt.Errorf("%s message isn't valid before %s", kind, notBefore)
+		}
+	}
+	return nil
+}
+
+// ParseSIWxMessage parses a CAIP-122 Sign-In With X message, returning false if the message isn't one.
+func ParseSIWxMessage(message string) (*SIWxMessage, bool) {
+	lines := strings.Split(message, "\n")
+	if len(lines) < 2 {
+		return nil, false
+	}
+	infix := strings.Index(lines[0], siwxHeaderInfix)
+	if infix < 1 || !strings.HasSuffix(lines[0], " account:") {
+		return nil, false
+	}
+	chainName := strings.TrimSuffix(lines[0][infix+len(siwxHeaderInfix):], " account:")
+	if chainName == "" {
+		return nil, false
+	}
+
+	siwx := &SIWxMessage{
+		Domain:    lines[0][:infix],
+		ChainName: chainName,
+		Address:   lines[1],
+	}
+
+	fields := map[string]*string{
+		"URI":             &siwx.URI,
+		"Version":         &siwx.Version,
+		"Chain ID":        &siwx.ChainID,
+		"Nonce":           &siwx.Nonce,
+		"Issued At":       &siwx.IssuedAt,
+		"Expiration Time": &siwx.ExpirationTime,
+		"Not Before":      &siwx.NotBefore,
+		"Request ID":      &siwx.RequestID,
+	}
+
+	inResources := false
+	for i, line := range lines[2:] {
+		if inResources && strings.HasPrefix(line, "- ") {
+			siwx.Resources = append(siwx.Resources, strings.TrimPrefix(line, "- "))
+			continue
+		}
+		inResources = false
+
+		if line == "Resources:" {
+			inResources = true
+			continue
+		}
+		if parts := strings.SplitN(line, ": ", 2); len(parts) == 2 {
+			if field, ok := fields[parts[0]]; ok {
+				*field = parts[1]
+				continue
+			}
+		}
+		// the statement is the only line between the blank lines after the address
+		if i == 1 && line != "" && siwx.Statement == "" {
+			siwx.Statement = line
+		}
+	}
+	return siwx, true
+}
+
+// String formats the message as CAIP-122 text, to issue it as a challenge.
+func (m *SIWxMessage) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s%s%s account:\n%s\n", m.Domain, siwxHeaderInfix, m.ChainName, m.Address)
+	if m.Statement != "" {
+		fmt.Fprintf(&sb, "\n%s\n", m.Statement)
+	}
+	sb.WriteString("\n")
+
+	fields := []struct{ name, value string }{
+		{"URI", m.URI},
+		{"Version", m.Version},
+		{"Chain ID", m.ChainID},
+		{"Nonce", m.Nonce},
+		{"Issued At", m.IssuedAt},
+		{"Expiration Time", m.ExpirationTime},
+		{"Not Before", m.NotBefore},
+		{"Request ID", m.RequestID},
+	}
+	lines := []string{}
+	for _, field := range fields {
+		if field.value != "" {
+			lines = append(lines, field.name+": "+field.value)
+		}
+	}
+	if len(m.Resources) > 0 {
+		lines = append(lines, "Resources:")
+		for _, resource := range m.Resources {
+			lines = append(lines, "- "+resource)
+		}
+	}
+	sb.WriteString(strings.Join(lines, "\n"))
+	return sb.String()
+}
@@ -0,0 +1,127 @@
+package dappauth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/dapperlabs/dappauth/dappauthtest"
+	"github.com/ethereum/go-ethereum/common"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestSIWx(t *testing.T) {
+
+	key, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+	ethAddress := ethCrypto.PubkeyToAddress(key.PublicKey).Hex()
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	checkError(err, t)
+	solAddress := base58Encode(publicKey)
+
+	now := func() time.Time { return time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC) }
+	solanaVerifier := NewSolanaVerifier("example.com")
+	solanaVerifier.now = now
+
+	siwx := NewSIWx("example.com",
+		SIWxNamespace{Namespace: "eip155", ChainName: "Ethereum", ChainIDs: []string{"1", "10"}, Verifier: NewAuthenticator(nil, dappauthtest.NewWallet(common.HexToAddress(ethAddress), 1))},
+		SIWxNamespace{Namespace: "solana", ChainName: "Solana", ChainIDs: []string{"mainnet"}, Verifier: solanaVerifier},
+	)
+	siwx.now = now
+
+	message := func(chainName, address, chainID string) *SIWxMessage {
+		return &SIWxMessage{
+			Domain:         "example.com",
+			ChainName:      chainName,
+			Address:        address,
+			Statement:      "Sign in to the marketplace",
+			URI:            "https://example.com",
+			Version:        "1",
+			ChainID:        chainID,
+			Nonce:          "32891756",
+			IssuedAt:       "2021-09-30T16:25:24Z",
+			ExpirationTime: "2021-10-02T00:00:00Z",
+			Resources:      []string{"https://example.com/terms"},
+		}
+	}
+
+	t.Run("Messages should round trip", func(t *testing.T) {
+		msg := message("Ethereum", ethAddress, "1")
+		parsed, ok := ParseSIWxMessage(msg.String())
+		expectBool(ok, true, t)
+		expectString(parsed.String(), msg.String(), t)
+		expectString(parsed.Statement, msg.Statement, t)
+		expectString(parsed.ChainName, "Ethereum", t)
+
+		msg.Statement = ""
+		parsed, ok = ParseSIWxMessage(msg.String())
+		expectBool(ok, true, t)
+		expectString(parsed.Statement, "", t)
+		expectString(parsed.Nonce, msg.Nonce, t)
+
+		_, ok = ParseSIWxMessage("foo")
+		expectBool(ok, false, t)
+	})
+
+	t.Run("Messages should be dispatched to the verifier of their namespace", func(t *testing.T) {
+		msg := message("Ethereum", ethAddress, "1").String()
+		result, err := siwx.SignIn(msg, signEOAPersonalMessage(msg, key, t), "32891756")
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+		expectString(string(result.Method), string(MethodEOA), t)
+		expectString(result.Account, "eip155:1:"+ethAddress, t)
+		expectString(result.SIWx.Domain, "example.com", t)
+
+		msg = message("Solana", solAddress, "mainnet").String()
+		result, err = siwx.SignIn(msg, base58Encode(ed25519.Sign(privateKey, []byte(msg))), "32891756")
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+		expectString(string(result.Method), string(MethodSolana), t)
+		expectString(result.Account, "solana:mainnet:"+solAddress, t)
+
+		// signed by another key
+		result, err = siwx.SignIn(msg, base58Encode(ed25519.Sign(privateKey, []byte("foo"))), "32891756")
+		checkError(err, t)
+		expectBool(result.Authorized, false, t)
+	})
+
+	t.Run("Domain, nonce, chain and time bounds should be checked", func(t *testing.T) {
+		sign := func(msg *SIWxMessage) (string, string) {
+			text := msg.String()
+			return text, signEOAPersonalMessage(text, key, t)
+		}
+
+		for name, modify := range map[string]func(*SIWxMessage){
+			"other domain":      func(m *SIWxMessage) { m.Domain = "evil.com" },
+			"other nonce":       func(m *SIWxMessage) { m.Nonce = "1" },
+			"no nonce":          func(m *SIWxMessage) { m.Nonce = "" },
+			"no chain ID":       func(m *SIWxMessage) { m.ChainID = "" },
+			"other chain ID":    func(m *SIWxMessage) { m.ChainID = "137" },
+			"unsupported chain": func(m *SIWxMessage) { m.ChainName = "Bitcoin" },
+			"expired":           func(m *SIWxMessage) { m.ExpirationTime = "2021-09-30T00:00:00Z" },
+			"not yet valid":     func(m *SIWxMessage) { m.NotBefore = "2021-10-01T00:00:01Z" },
+			"bad issued at":     func(m *SIWxMessage) { m.IssuedAt = "yesterday" },
+		} {
+			msg := message("Ethereum", ethAddress, "1")
+			modify(msg)
+			text, sig := sign(msg)
+			if _, err := siwx.SignIn(text, sig, "32891756"); err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+		}
+	})
+
+	t.Run("The issued nonce should be required", func(t *testing.T) {
+		msg := message("Ethereum", ethAddress, "10").String()
+		sig := signEOAPersonalMessage(msg, key, t)
+
+		_, err := siwx.SignIn(msg, sig, "")
+		expectBool(err != nil, true, t)
+
+		result, err := siwx.SignIn(msg, sig, "32891756")
+		checkError(err, t)
+		expectString(result.Account, "eip155:10:"+ethAddress, t)
+	})
+}
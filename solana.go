@@ -0,0 +1,112 @@
+package dappauth
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// SIWSMessage is a parsed Sign-In With Solana message.
+type SIWSMessage struct {
+	Domain         string
+	Address        string
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        string
+	Nonce          string
+	IssuedAt       string
+	ExpirationTime string
+	NotBefore      string
+	RequestID      string
+	Resources      []string
+}
+
+// SolanaVerifier verifies ed25519 signatures of Solana wallets, including Sign-In With Solana messages.
+type SolanaVerifier struct {
+	domain string           // Domain SIWS messages must be for (empty = any)
+	now    func() time.Time // Clock of the SIWS time checks
+}
+
+// NewSolanaVerifier creates a new SolanaVerifier . If domain is set, SIWS messages must be for it.
+func NewSolanaVerifier(domain string) *SolanaVerifier {
+	return &SolanaVerifier{
+		domain: domain,
+		now:    time.Now,
+	}
+}
+
+// Verify checks the ed25519 signature (base58, or 0x prefixed hex) of the challenge by the base58 address.
+// Like Phantom's signMessage of the encoded text, the signed message is the text of the challenge, so hex challenges are not decoded.
+// If the challenge is a SIWS message, it must be for the address and domain, and valid now.
+func (v *SolanaVerifier) Verify(challenge, signature, address string) (*Result, error) {
+	publicKey, err := base58Decode(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Solana address %q: %v", address, err)
+	}
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid Solana address %q: %d bytes", address, len(publicKey))
+	}
+
+	var sig []byte
+	if strings.HasPrefix(signature, "0x") {
+		sig = common.FromHex(signature)
+	} else if sig, err = base58Decode(signature); err != nil {
+		return nil, fmt.Errorf("invalid Solana signature: %v", err)
+	}
+	if len(sig) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("invalid signature length %d, expected %d", len(sig), ed25519.SignatureSize)
+	}
+
+	message := []byte(challenge)
+	result := &Result{Address: base58Encode(publicKey)}
+
+	if siws, ok := ParseSIWSMessage(string(message)); ok {
+		result.SIWS = siws
+		if err := v.checkSIWS(siws, result.Address); err != nil {
+			return nil, err
+		}
+	}
+
+	if ed25519.Verify(publicKey, message, sig) {
+		result.Authorized, result.Method = true, MethodSolana
+	}
+	return result, nil
+}
+
+// checkSIWS checks the message is for the address and domain, and valid now.
+func (v *SolanaVerifier) checkSIWS(siws *SIWSMessage, address string) error {
+	if siws.Address != address {
+		return fmt.Errorf("SIWS message is for address %s", siws.Address)
+	}
+	if v.domain != "" && siws.Domain != v.domain {
+		return fmt.Errorf("SIWS message is for domain %s", siws.Domain)
+	}
+
+	return checkSignInTimes("SIWS", v.now(), siws.ExpirationTime, siws.NotBefore)
+}
+
+// ParseSIWSMessage parses a Sign-In With Solana message, returning false if the message isn't one.
+func ParseSIWSMessage(message string) (*SIWSMessage, bool) {
+	siwx, ok := ParseSIWxMessage(message)
+	if !ok || siwx.ChainName != "Solana" {
+		return nil, false
+	}
+	return &SIWSMessage{
+		Domain:         siwx.Domain,
+		Address:        siwx.Address,
+		Statement:      siwx.Statement,
+		URI:            siwx.URI,
+		Version:        siwx.Version,
+		ChainID:        siwx.ChainID,
+		Nonce:          siwx.Nonce,
+		IssuedAt:       siwx.IssuedAt,
+		ExpirationTime: siwx.ExpirationTime,
+		NotBefore:      siwx.NotBefore,
+		RequestID:      siwx.RequestID,
+		Resources:      siwx.Resources,
+	}, true
+}
@@ -0,0 +1,118 @@
+package dappauth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBase58(t *testing.T) {
+	expectString(base58Encode([]byte("Hello World!")), "2NEpo7TZRRrLZSi2U", t)
+	expectString(base58Encode([]byte{0, 0, 1}), "112", t)
+
+	decoded, err := base58Decode("112")
+	checkError(err, t)
+	expectString(hex.EncodeToString(decoded), "000001", t)
+
+	_, err = base58Decode("0OIl")
+	expectBool(err != nil, true, t)
+}
+
+func siwsMessage(domain, address, expiration string) string {
+	return fmt.Sprintf(`%s wants you to sign in with your Solana account:
+%s
+
+Sign in to the marketplace
+
+URI: https://%s
+Version: 1
+Chain ID: mainnet
+Nonce: 32891756
+Issued At: 2021-09-30T16:25:24Z
+Expiration Time: %s
+Resources:
+- https://%s/terms`, domain, address, domain, expiration, domain)
+}
+
+func TestSolanaVerifier(t *testing.T) {
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	checkError(err, t)
+	address := base58Encode(publicKey)
+	otherPublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	checkError(err, t)
+
+	verifier := NewSolanaVerifier("example.com")
+	verifier.now = func() time.Time { return time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC) }
+
+	sign := func(message string) string {
+		return base58Encode(ed25519.Sign(privateKey, []byte(message)))
+	}
+
+	t.Run("Plain messages signed by the address should be authorized", func(t *testing.T) {
+		result, err := verifier.Verify("foo", sign("foo"), address)
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+		expectString(string(result.Method), string(MethodSolana), t)
+		expectBool(result.SIWS == nil, true, t)
+
+		// hex signatures are accepted too
+		result, err = verifier.Verify("foo", "0x"+hex.EncodeToString(ed25519.Sign(privateKey, []byte("foo"))), address)
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+
+		// hex challenges, such as random nonces, are signed as text
+		result, err = verifier.Verify("0x4f3a9c2e1b7d", sign("0x4f3a9c2e1b7d"), address)
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+
+		result, err = verifier.Verify("4f3a9c2e1b7d", sign("4f3a9c2e1b7d"), address)
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+	})
+
+	t.Run("Messages signed by another key should NOT be authorized", func(t *testing.T) {
+		result, err := verifier.Verify("foo", sign("foo"), base58Encode(otherPublicKey))
+		checkError(err, t)
+		expectBool(result.Authorized, false, t)
+
+		result, err = verifier.Verify("bar", sign("foo"), address)
+		checkError(err, t)
+		expectBool(result.Authorized, false, t)
+	})
+
+	t.Run("SIWS messages should be parsed and authorized", func(t *testing.T) {
+		message := siwsMessage("example.com", address, "2021-10-02T00:00:00Z")
+
+		result, err := verifier.Verify(message, sign(message), address)
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+		expectString(result.SIWS.Domain, "example.com", t)
+		expectString(result.SIWS.Statement, "Sign in to the marketplace", t)
+		expectString(result.SIWS.Nonce, "32891756", t)
+		expectString(result.SIWS.ChainID, "mainnet", t)
+		expectBool(len(result.SIWS.Resources) == 1, true, t)
+	})
+
+	t.Run("SIWS messages for another address, domain or time should error", func(t *testing.T) {
+		for _, message := range []string{
+			siwsMessage("example.com", base58Encode(otherPublicKey), "2021-10-02T00:00:00Z"),
+			siwsMessage("evil.com", address, "2021-10-02T00:00:00Z"),
+			siwsMessage("example.com", address, "2021-09-30T17:00:00Z"),
+		} {
+			_, err := verifier.Verify(message, sign(message), address)
+			expectBool(err != nil, true, t)
+		}
+	})
+
+	t.Run("Malformed addresses and signatures should error", func(t *testing.T) {
+		_, err := verifier.Verify("foo", sign("foo"), "0xabcd")
+		expectBool(err != nil, true, t)
+
+		_, err = verifier.Verify("foo", "abc", address)
+		expectBool(err != nil, true, t)
+	})
+}
@@ -0,0 +1,202 @@
+package dappauth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Verifier verifies whether a signature of a challenge authorizes an address.
+// Authenticator, SafeVerifier, Simulator, FlowVerifier, SolanaVerifier, BitcoinVerifier, CosmosVerifier, EOAVerifier, ERC1271Verifier and Strategy implement it.
+type Verifier interface {
+	Verify(challenge, signature, addrHex string) (*Result, error)
+}
+
+// EOAVerifier verifies signatures recovered directly to the address.
+type EOAVerifier struct {
+	a *Authenticator
+}
+
+// NewEOAVerifier creates a new EOAVerifier of the personal message prefix and address encoding of the profile, e.g. EthereumProfile .
+func NewEOAVerifier(profile MessageProfile) *EOAVerifier {
+	return &EOAVerifier{a: NewAuthenticator(nil, nil, WithMessageProfile(profile))}
+}
+
+// Verify checks if the signature was signed by the key of the address.
+func (v *EOAVerifier) Verify(challenge, signature, addrHex string) (*Result, error) {
+	addr, err := v.a.messageProfile.parseAddress(addrHex)
+	if err != nil {
+		return nil, err
+	}
+
+	isEOA, err := v.a.checkEOA(challenge, common.FromHex(signature), addr)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Address: v.a.messageProfile.formatAddress(addr)}
+	if isEOA {
+		result.Authorized, result.Method = true, MethodEOA
+	}
+	return result, nil
+}
+
+// ERC1271Verifier verifies signatures approved by the contract at the address via ERC-1271.
+type ERC1271Verifier struct {
+	a *Authenticator
+}
+
+// NewERC1271Verifier creates a new ERC1271Verifier . The options of the contract path apply, like WithHashMode, WithContractCall and WithChains.
+func NewERC1271Verifier(ctx context.Context, cc bind.ContractCaller, opts ...Option) *ERC1271Verifier {
+	return &ERC1271Verifier{a: NewAuthenticator(ctx, cc, opts...)}
+}
+
+// Verify checks if the contract at the address approves the signature, probing the chains of WithChains if the address has no code.
+func (v *ERC1271Verifier) Verify(challenge, signature, addrHex string) (*Result, error) {
+	addr, err := v.a.messageProfile.parseAddress(addrHex)
+	if err != nil {
+		return nil, err
+	}
+	origSigBytes := common.FromHex(signature)
+
+	isContract, hashMode, returnShape, errCA := v.a.checkContract(challenge, origSigBytes, addr)
+	result := &Result{Address: v.a.messageProfile.formatAddress(addr)}
+
+	// try the contract wallet on other chains, if it isn't deployed on the main chain
+	if !isContract && len(v.a.chains) > 0 {
+		result.ChainProbes, err = v.a.probeChains(challenge, origSigBytes, addr)
+		if err != nil {
+			return nil, err
+		}
+		for _, probe := range result.ChainProbes {
+			if probe.Authorized {
+				result.Authorized, result.Method, result.HashMode, result.ReturnShape = true, MethodERC1271, probe.HashMode, probe.ReturnShape
+				return result, nil
+			}
+		}
+	}
+
+	if errCA != nil {
+		return nil, errCA
+	}
+	if isContract {
+		result.Authorized, result.Method, result.HashMode, result.ReturnShape = true, MethodERC1271, hashMode, returnShape
+	}
+	return result, nil
+}
+
+// StrategyMode sets how a Strategy combines its verifiers.
+type StrategyMode int
+
+const (
+	// StrategyAny authorizes with the result of the first verifier that authorizes, in order.
+	// Errors of verifiers are only returned if none authorized.
+	StrategyAny StrategyMode = iota
+	// StrategyAll authorizes only if every verifier authorizes, stopping at the first that doesn't or errors.
+	StrategyAll
+)
+
+// Strategy runs an ordered chain of verifiers.
+type Strategy struct {
+	mode        StrategyMode
+	verifiers   []Verifier
+	mergeErrors func(errs []error) error // Combines the errors of the verifiers (by index) of StrategyAny when none authorized (nil = first error)
+}
+
+// NewStrategy creates a new Strategy .
+func NewStrategy(mode StrategyMode, verifiers ...Verifier) *Strategy {
+	return &Strategy{mode: mode, verifiers: verifiers}
+}
+
+// IsAuthorizedSigner checks if the verifiers authorize the address, like Authenticator.IsAuthorizedSigner .
+func (s *Strategy) IsAuthorizedSigner(challenge, signature, addrHex string) (bool, error) {
+	result, err := s.Verify(challenge, signature, addrHex)
+	if err != nil {
+		return false, err
+	}
+	return result.Authorized, nil
+}
+
+// Verify runs the verifiers in order. With StrategyAny the authorizing result is returned as is,
+// with StrategyAll the result lists the result of each verifier.
+func (s *Strategy) Verify(challenge, signature, addrHex string) (*Result, error) {
+	if len(s.verifiers) == 0 {
+		return nil, fmt.Errorf("strategy has no verifiers")
+	}
+
+	switch s.mode {
+	case StrategyAny:
+		var lastResult *Result
+		errs := make([]error, len(s.verifiers))
+		for i, verifier := range s.verifiers {
+			result, err := verifier.Verify(challenge, signature, addrHex)
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+			if result.Authorized {
+				return result, nil
+			}
+			lastResult = result
+		}
+
+		mergeErrors := s.mergeErrors
+		if mergeErrors == nil {
+			mergeErrors = firstError
+		}
+		if err := mergeErrors(errs); err != nil {
+			return nil, err
+		}
+		return lastResult, nil
+
+	case StrategyAll:
+		combined := &Result{Authorized: true}
+		for _, verifier := range s.verifiers {
+			result, err := verifier.Verify(challenge, signature, addrHex)
+			if err != nil {
+				return nil, err
+			}
+			combined.Results = append(combined.Results, result)
+			if combined.Address == "" {
+				combined.Address = result.Address
+			}
+			if !result.Authorized {
+				combined.Authorized = false
+				break
+			}
+		}
+		if combined.Authorized {
+			combined.Method = MethodAll
+		}
+		return combined, nil
+
+	default:
+		return nil, fmt.Errorf("unknown strategy mode %d", s.mode)
+	}
+}
+
+// firstError returns the first of the errors that isn't nil.
+func firstError(errs []error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	_ Verifier = (*Authenticator)(nil)
+	_ Verifier = (*SafeVerifier)(nil)
+	_ Verifier = (*Simulator)(nil)
+	_ Verifier = (*FlowVerifier)(nil)
+	_ Verifier = (*SolanaVerifier)(nil)
+	_ Verifier = (*BitcoinVerifier)(nil)
+	_ Verifier = (*CosmosVerifier)(nil)
+	_ Verifier = (*EOAVerifier)(nil)
+	_ Verifier = (*ERC1271Verifier)(nil)
+	_ Verifier = (*delegateRegistryVerifier)(nil)
+	_ Verifier = (*Strategy)(nil)
+)
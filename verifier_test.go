@@ -0,0 +1,131 @@
+package dappauth
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// attestationVerifier is an in-house strategy authorizing a fixed set of addresses.
+type attestationVerifier struct {
+	attested map[common.Address]bool
+	err      error
+	calls    int
+}
+
+func (v *attestationVerifier) Verify(challenge, signature, addrHex string) (*Result, error) {
+	v.calls++
+	if v.err != nil {
+		return nil, v.err
+	}
+	addr := common.HexToAddress(addrHex)
+	return &Result{Authorized: v.attested[addr], Address: addr.Hex(), Method: "attestation"}, nil
+}
+
+func TestVerifiers(t *testing.T) {
+
+	key, err := ethCrypto.GenerateKey()
+	checkError(err, t)
+	eoa := ethCrypto.PubkeyToAddress(key.PublicKey)
+	eoaSignature := signEOAPersonalMessage("foo", key, t)
+
+	wallet := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	simulator := NewSimulator(nil, &mockStateReader{
+		header:  &types.Header{Number: big.NewInt(12244000), GasLimit: 15000000, Difficulty: new(big.Int)},
+		code:    map[common.Address][]byte{wallet: common.FromHex(approvedHashWalletCode)},
+		storage: map[common.Address]map[common.Hash]common.Hash{wallet: {{}: scMessageHash("foo")}},
+	}, SimulatorConfig{})
+
+	t.Run("EOAVerifier should only authorize the signing key's address", func(t *testing.T) {
+		result, err := NewEOAVerifier(EthereumProfile).Verify("foo", eoaSignature, eoa.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+		expectString(string(result.Method), string(MethodEOA), t)
+
+		result, err = NewEOAVerifier(EthereumProfile).Verify("foo", eoaSignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, false, t)
+
+		_, err = NewEOAVerifier(EthereumProfile).Verify("foo", "0x00", eoa.Hex())
+		expectBool(err != nil, true, t)
+	})
+
+	t.Run("EOAVerifier should use the prefix and address encoding of its profile", func(t *testing.T) {
+		tronSignature, err := NewSigner(key).SignPrefixedMessage(TronMessagePrefix, "foo")
+		checkError(err, t)
+
+		result, err := NewEOAVerifier(TronProfile).Verify("foo", tronSignature, FormatTronAddress(eoa))
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+		expectString(result.Address, FormatTronAddress(eoa), t)
+
+		result, err = NewEOAVerifier(EthereumProfile).Verify("foo", tronSignature, eoa.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, false, t)
+	})
+
+	t.Run("ERC1271Verifier should only authorize approving contracts", func(t *testing.T) {
+		verifier := NewERC1271Verifier(nil, simulator, WithHashMode(HashModeRaw))
+
+		result, err := verifier.Verify("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+		expectString(string(result.Method), string(MethodERC1271), t)
+
+		result, err = verifier.Verify("bar", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, false, t)
+	})
+
+	t.Run("StrategyAny should short-circuit on the first authorizing verifier", func(t *testing.T) {
+		attestation := &attestationVerifier{attested: map[common.Address]bool{eoa: true}}
+		strategy := NewStrategy(StrategyAny, NewEOAVerifier(EthereumProfile), attestation)
+
+		result, err := strategy.Verify("foo", eoaSignature, eoa.Hex())
+		checkError(err, t)
+		expectString(string(result.Method), string(MethodEOA), t)
+		expectBool(attestation.calls == 0, true, t)
+
+		// the EOA check errors on the contract signature, the attestation decides
+		result, err = strategy.Verify("foo", dummySignature, eoa.Hex())
+		checkError(err, t)
+		expectString(string(result.Method), "attestation", t)
+	})
+
+	t.Run("StrategyAny should only error if no verifier authorized", func(t *testing.T) {
+		strategy := NewStrategy(StrategyAny, &attestationVerifier{err: errors.New("Dummy error")}, NewERC1271Verifier(nil, simulator))
+
+		isAuthorizedSigner, err := strategy.IsAuthorizedSigner("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, true, t)
+
+		_, err = strategy.IsAuthorizedSigner("bar", dummySignature, wallet.Hex())
+		expectBool(err != nil, true, t)
+	})
+
+	t.Run("StrategyAll should require every verifier", func(t *testing.T) {
+		attestation := &attestationVerifier{attested: map[common.Address]bool{wallet: true}}
+		strategy := NewStrategy(StrategyAll, attestation, NewAuthenticator(nil, simulator))
+
+		result, err := strategy.Verify("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, true, t)
+		expectString(string(result.Method), string(MethodAll), t)
+		expectBool(len(result.Results) == 2, true, t)
+
+		result, err = strategy.Verify("bar", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, false, t)
+
+		attestation.attested[wallet] = false
+		calls := attestation.calls
+		result, err = strategy.Verify("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(result.Authorized, false, t)
+		expectBool(len(result.Results) == 1 && attestation.calls == calls+1, true, t)
+	})
+}
@@ -0,0 +1,116 @@
+package dappauth
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// WalletVersion identifies a known wallet implementation.
+type WalletVersion struct {
+	Family  string // e.g. "Safe"
+	Version string // e.g. "1.3.0"
+}
+
+// WalletRegistry maps known wallet implementations to their family and version.
+type WalletRegistry struct {
+	Implementations map[common.Address]WalletVersion // By implementation (or singleton) address
+	CodeHashes      map[common.Hash]WalletVersion    // By runtime code hash of the wallet or its implementation
+}
+
+// KnownWallets holds the mainnet deployments of the Safe singletons and of the ERC-4337 account implementations
+// (Coinbase Smart Wallet, Kernel, LightAccount and SimpleAccount), and can be extended or copied.
+// The code hashes identify the Safe v1.3.0 singletons wherever they are deployed, like at their EIP-155 addresses on L2s.
+var KnownWallets = WalletRegistry{
+	Implementations: map[common.Address]WalletVersion{
+		common.HexToAddress("0xb6029EA3B2c51D09a50B53CA8012FeEB05bDa35A"): {"Safe", "1.0.0"},
+		common.HexToAddress("0x34CfAC646f301356fAa8B21e94227e3583Fe3F5F"): {"Safe", "1.1.1"},
+		common.HexToAddress("0x6851D6fDFAfD08c0295C392436245E5bc78B0185"): {"Safe", "1.2.0"},
+		common.HexToAddress("0xd9Db270c1B5E3Bd161E8c8503c55cEABeE709552"): {"Safe", "1.3.0"},
+		common.HexToAddress("0x69f4D1788e39c87893C980c06EdF4b7f686e2938"): {"Safe", "1.3.0"},
+		common.HexToAddress("0x3E5c63644E683549055b9Be8653de26E0B4CD36E"): {"Safe", "1.3.0+L2"},
+		common.HexToAddress("0xfb1bffC9d739B8D520DaF37dF666da4C687191EA"): {"Safe", "1.3.0+L2"},
+		common.HexToAddress("0x41675C099F32341bf84BFc5382aF534df5C7461a"): {"Safe", "1.4.1"},
+		common.HexToAddress("0x29fcB43b46531BcA003ddC8FCB67FFE91900C762"): {"Safe", "1.4.1+L2"},
+		common.HexToAddress("0x000100abaad02f1cfC8Bbe32bD5a564817339E72"): {"Coinbase Smart Wallet", "1"},
+		common.HexToAddress("0x94F097E1ebEB4ecA3AAE54cabb08905B239A7D27"): {"Kernel", "3.0"},
+		common.HexToAddress("0xBAC849bB641841b44E965fB01A4Bf5F074f84b4D"): {"Kernel", "3.1"},
+		common.HexToAddress("0xae8c656ad28F2B59a196AB61815C16A0AE1c3cba"): {"LightAccount", "1.1.0"},
+		common.HexToAddress("0x8E8e658E22B12ada97B402fF0b044D6A325013C7"): {"LightAccount", "2.0.0"},
+		common.HexToAddress("0x8ABB13360b87Be5EEb1B98647A016adD927a136c"): {"SimpleAccount", "0.6.0"},
+	},
+	CodeHashes: map[common.Hash]WalletVersion{
+		common.HexToHash("0xbba688fbdb21ad2bb58bc320638b43d94e7d100f6f3ebaab0a4e4de6304b1c2e"): {"Safe", "1.3.0"},
+		common.HexToHash("0x21842597390c4c6e3c1239e434a682b054bd9548eee5e9b1d6a4482731023c0f"): {"Safe", "1.3.0+L2"},
+	},
+}
+
+// WalletInfo describes the contract at a verified address.
+type WalletInfo struct {
+	CodeHash       common.Hash     // Runtime code hash of the contract
+	ProxyType      ProxyType       // (empty if not a detected proxy)
+	Implementation *common.Address // The implementation of a proxy
+	Admin          *common.Address // The admin of an EIP-1967 proxy (nil if unset)
+	Family         string          // Family of a known wallet (empty if unknown)
+	Version        string          // Version of a known wallet (empty if unknown)
+}
+
+// WithWalletDetection attaches a WalletInfo to results of the contract path, matching implementations against the registry.
+// It costs up to 4 extra calls per verification. Detection errors are reported in the result, they don't fail the verification.
+func WithWalletDetection(registry WalletRegistry) Option {
+	return func(a *Authenticator) {
+		a.walletRegistry = &registry
+	}
+}
+
+// detectWallet identifies the contract at the address, or returns nil if there is no code.
+func (a *Authenticator) detectWallet(addr common.Address) (*WalletInfo, error) {
+	code, err := a.cc.CodeAt(a.ctx, addr, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Wallet detection code lookup errored with: '%v'", err)
+	}
+	if len(code) == 0 {
+		return nil, nil
+	}
+
+	info := &WalletInfo{CodeHash: ethCrypto.Keccak256Hash(code)}
+	info.ProxyType, info.Implementation, err = a.resolveProxy(addr, code)
+	if err != nil {
+		return nil, err
+	}
+
+	switch info.ProxyType {
+	case ProxyTypeEIP1967:
+		info.Admin, err = a.addressAt(addr, EIP1967AdminSlot)
+		if err != nil {
+			return nil, fmt.Errorf("EIP-1967 admin lookup errored with: '%v'", err)
+		}
+	case "":
+		// Safe proxies keep their singleton in the first slot, which is only trusted if it is a known singleton
+		singleton, err := a.addressAt(addr, common.Hash{})
+		if err != nil {
+			return nil, fmt.Errorf("Safe singleton lookup errored with: '%v'", err)
+		}
+		if singleton != nil {
+			if _, ok := a.walletRegistry.Implementations[*singleton]; ok {
+				info.ProxyType, info.Implementation = ProxyTypeSafe, singleton
+			}
+		}
+	}
+
+	version, ok := a.walletRegistry.CodeHashes[info.CodeHash]
+	if !ok && info.Implementation != nil {
+		version, ok = a.walletRegistry.Implementations[*info.Implementation]
+		if !ok && len(a.walletRegistry.CodeHashes) > 0 {
+			implementationCode, err := a.cc.CodeAt(a.ctx, *info.Implementation, nil)
+			if err != nil {
+				return nil, fmt.Errorf("Wallet detection code lookup errored with: '%v'", err)
+			}
+			version = a.walletRegistry.CodeHashes[ethCrypto.Keccak256Hash(implementationCode)]
+		}
+	}
+	info.Family, info.Version = version.Family, version.Version
+
+	return info, nil
+}
@@ -0,0 +1,86 @@
+package dappauth
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// WalletAllowlist lists the wallet implementations trusted to answer ERC-1271 calls.
+type WalletAllowlist struct {
+	CodeHashes      []common.Hash    // Runtime code hashes of wallets or proxy implementations
+	Implementations []common.Address // Addresses of proxy implementations
+	ProxyCodeHashes []common.Hash    // Runtime code hashes of proxies trusted to delegate to the implementation in their EIP-1967 slot (or first slot, like Safe proxies)
+}
+
+// WithWalletAllowlist only runs the ERC-1271 path for contracts whose runtime code hash is allowed,
+// or whose EIP-7702, EIP-1167 or trusted EIP-1967/Safe proxy resolves to an allowed implementation. Other contracts are not authorized.
+func WithWalletAllowlist(allowlist WalletAllowlist) Option {
+	return func(a *Authenticator) {
+		a.walletAllowlist = &allowlist
+	}
+}
+
+// walletAllowed checks the contract at the address against the allowlist.
+func (a *Authenticator) walletAllowed(addr common.Address) (bool, error) {
+	code, err := a.cc.CodeAt(a.ctx, addr, nil)
+	if err != nil {
+		return false, fmt.Errorf("Wallet allowlist code lookup errored with: '%v'", err)
+	}
+	codeHash := ethCrypto.Keccak256Hash(code)
+	if hasHash(a.walletAllowlist.CodeHashes, codeHash) {
+		return true, nil
+	}
+
+	implementation, err := a.allowlistImplementation(addr, code, codeHash)
+	if err != nil || implementation == nil {
+		return false, err
+	}
+	for _, allowed := range a.walletAllowlist.Implementations {
+		if *implementation == allowed {
+			return true, nil
+		}
+	}
+
+	implementationCode, err := a.cc.CodeAt(a.ctx, *implementation, nil)
+	if err != nil {
+		return false, fmt.Errorf("Wallet allowlist code lookup errored with: '%v'", err)
+	}
+	return hasHash(a.walletAllowlist.CodeHashes, ethCrypto.Keccak256Hash(implementationCode)), nil
+}
+
+// allowlistImplementation returns the implementation the code at the address delegates to, or nil if it isn't a trusted proxy.
+// The code of EIP-7702 delegations and EIP-1167 proxies names the implementation, but any contract can store an address
+// in the EIP-1967 or first slot while answering ERC-1271 calls itself, so slots are only followed for trusted proxy code.
+func (a *Authenticator) allowlistImplementation(addr common.Address, code []byte, codeHash common.Hash) (*common.Address, error) {
+	if delegate := parseDelegation(code); delegate != nil {
+		return delegate, nil
+	}
+	if implementation := parseMinimalProxy(code); implementation != nil {
+		return implementation, nil
+	}
+	if !hasHash(a.walletAllowlist.ProxyCodeHashes, codeHash) {
+		return nil, nil
+	}
+
+	for _, slot := range []common.Hash{EIP1967ImplementationSlot, {}} {
+		implementation, err := a.addressAt(addr, slot)
+		if err != nil {
+			return nil, fmt.Errorf("Wallet allowlist implementation lookup errored with: '%v'", err)
+		}
+		if implementation != nil {
+			return implementation, nil
+		}
+	}
+	return nil, nil
+}
+
+func hasHash(hashes []common.Hash, hash common.Hash) bool {
+	for _, h := range hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
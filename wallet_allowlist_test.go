@@ -0,0 +1,113 @@
+package dappauth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// delegates all calls to the implementation in the EIP-1967 slot
+	eip1967ProxyCode = "0x366000600037600060003660007f360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc545af43d600060003e603e573d6000fd5b3d6000f3"
+	// delegates all calls to the singleton in the first slot, like Safe proxies
+	safeProxyCode = "0x366000600037600060003660006000545af43d600060003e601f573d6000fd5b3d6000f3"
+)
+
+func TestWalletAllowlist(t *testing.T) {
+
+	wallet := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	implementation := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	singleton := common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	walletCode := common.FromHex(approvedHashWalletCode)
+	walletCodeHash := ethCrypto.Keccak256Hash(walletCode)
+	minimalProxyCode := append(append(append([]byte{}, _EIP1167Prefix...), implementation.Bytes()...), _EIP1167Suffix...)
+	eip1967ProxyCode := common.FromHex(eip1967ProxyCode)
+	safeProxyCode := common.FromHex(safeProxyCode)
+
+	newAuthenticator := func(code []byte, storage map[common.Hash]common.Hash, allowlist WalletAllowlist) *Authenticator {
+		if _, ok := storage[common.Hash{}]; !ok {
+			storage[common.Hash{}] = scMessageHash("foo")
+		}
+		simulator := NewSimulator(nil, &mockStateReader{
+			header: &types.Header{Number: big.NewInt(12244000), GasLimit: 15000000, Difficulty: new(big.Int)},
+			code: map[common.Address][]byte{
+				wallet:         code,
+				implementation: walletCode,
+				singleton:      forgedWalletCode,
+			},
+			storage: map[common.Address]map[common.Hash]common.Hash{wallet: storage},
+		}, SimulatorConfig{})
+		return NewAuthenticator(nil, simulator, WithWalletAllowlist(allowlist))
+	}
+
+	t.Run("Allowed code hashes should be verified", func(t *testing.T) {
+		authenticator := newAuthenticator(walletCode, map[common.Hash]common.Hash{}, WalletAllowlist{CodeHashes: []common.Hash{walletCodeHash}})
+
+		isAuthorizedSigner, err := authenticator.IsAuthorizedSigner("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, true, t)
+	})
+
+	t.Run("Other contracts should NOT be authorized", func(t *testing.T) {
+		authenticator := newAuthenticator(walletCode, map[common.Hash]common.Hash{}, WalletAllowlist{CodeHashes: []common.Hash{{1}}})
+
+		isAuthorizedSigner, err := authenticator.IsAuthorizedSigner("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, false, t)
+	})
+
+	t.Run("EIP-1167 proxies of allowed code hashes should be verified", func(t *testing.T) {
+		authenticator := newAuthenticator(minimalProxyCode, map[common.Hash]common.Hash{}, WalletAllowlist{CodeHashes: []common.Hash{walletCodeHash}})
+
+		isAuthorizedSigner, err := authenticator.IsAuthorizedSigner("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, true, t)
+	})
+
+	t.Run("Trusted EIP-1967 proxies of allowed implementations should be verified", func(t *testing.T) {
+		storage := map[common.Hash]common.Hash{EIP1967ImplementationSlot: common.BytesToHash(implementation.Bytes())}
+		allowlist := WalletAllowlist{Implementations: []common.Address{implementation}, ProxyCodeHashes: []common.Hash{ethCrypto.Keccak256Hash(eip1967ProxyCode)}}
+		authenticator := newAuthenticator(eip1967ProxyCode, storage, allowlist)
+
+		isAuthorizedSigner, err := authenticator.IsAuthorizedSigner("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, true, t)
+
+		authenticator = newAuthenticator(eip1967ProxyCode, map[common.Hash]common.Hash{}, allowlist)
+		isAuthorizedSigner, err = authenticator.IsAuthorizedSigner("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, false, t)
+	})
+
+	t.Run("Trusted Safe proxies of allowed singletons should be verified", func(t *testing.T) {
+		storage := map[common.Hash]common.Hash{{}: common.BytesToHash(singleton.Bytes())}
+		allowlist := WalletAllowlist{CodeHashes: []common.Hash{ethCrypto.Keccak256Hash(forgedWalletCode)}, ProxyCodeHashes: []common.Hash{ethCrypto.Keccak256Hash(safeProxyCode)}}
+		authenticator := newAuthenticator(safeProxyCode, storage, allowlist)
+
+		isAuthorizedSigner, err := authenticator.IsAuthorizedSigner("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, true, t)
+	})
+
+	t.Run("Proxies with untrusted code should NOT be followed", func(t *testing.T) {
+		// an always valid contract naming an allowed implementation in its slots, without delegating to it
+		storage := map[common.Hash]common.Hash{
+			EIP1967ImplementationSlot: common.BytesToHash(implementation.Bytes()),
+			{}:                        common.BytesToHash(implementation.Bytes()),
+		}
+		authenticator := newAuthenticator(forgedWalletCode, storage, WalletAllowlist{Implementations: []common.Address{implementation}, CodeHashes: []common.Hash{walletCodeHash}})
+
+		isAuthorizedSigner, err := authenticator.IsAuthorizedSigner("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, false, t)
+
+		// delegating proxy code isn't followed either unless it is listed
+		authenticator = newAuthenticator(eip1967ProxyCode, storage, WalletAllowlist{Implementations: []common.Address{implementation}})
+		isAuthorizedSigner, err = authenticator.IsAuthorizedSigner("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		expectBool(isAuthorizedSigner, false, t)
+	})
+}
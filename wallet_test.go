@@ -0,0 +1,120 @@
+package dappauth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestWalletDetection(t *testing.T) {
+
+	wallet := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	implementation := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	admin := common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	safeSingleton := common.HexToAddress("0xd9Db270c1B5E3Bd161E8c8503c55cEABeE709552")
+	coinbaseSmartWallet := common.HexToAddress("0x000100abaad02f1cfC8Bbe32bD5a564817339E72")
+	lightAccount := common.HexToAddress("0x8E8e658E22B12ada97B402fF0b044D6A325013C7")
+	walletCode := common.FromHex(approvedHashWalletCode)
+
+	verify := func(code []byte, storage map[common.Hash]common.Hash, registry WalletRegistry) *Result {
+		simulator := NewSimulator(nil, &mockStateReader{
+			header:  &types.Header{Number: big.NewInt(12244000), GasLimit: 15000000, Difficulty: new(big.Int)},
+			code:    map[common.Address][]byte{wallet: code, implementation: walletCode, coinbaseSmartWallet: walletCode, lightAccount: walletCode},
+			storage: map[common.Address]map[common.Hash]common.Hash{wallet: storage},
+		}, SimulatorConfig{})
+		authenticator := NewAuthenticator(nil, simulator, WithWalletDetection(registry))
+
+		result, err := authenticator.Verify("foo", dummySignature, wallet.Hex())
+		checkError(err, t)
+		checkError(result.WalletErr, t)
+		return result
+	}
+
+	t.Run("Known code hashes should be identified", func(t *testing.T) {
+		registry := WalletRegistry{CodeHashes: map[common.Hash]WalletVersion{ethCrypto.Keccak256Hash(walletCode): {"Test", "1.0.0"}}}
+		result := verify(walletCode, map[common.Hash]common.Hash{{}: scMessageHash("foo")}, registry)
+
+		expectBool(result.Authorized, true, t)
+		expectString(string(result.Wallet.ProxyType), "", t)
+		expectString(result.Wallet.Family, "Test", t)
+		expectString(result.Wallet.Version, "1.0.0", t)
+	})
+
+	t.Run("EIP-1167 proxies should be identified by their implementation code", func(t *testing.T) {
+		proxyCode := append(append(append([]byte{}, _EIP1167Prefix...), implementation.Bytes()...), _EIP1167Suffix...)
+		registry := WalletRegistry{CodeHashes: map[common.Hash]WalletVersion{ethCrypto.Keccak256Hash(walletCode): {"Test", "1.0.0"}}}
+		result := verify(proxyCode, map[common.Hash]common.Hash{}, registry)
+
+		expectString(string(result.Wallet.ProxyType), string(ProxyTypeEIP1167), t)
+		expectString(result.Wallet.Implementation.Hex(), implementation.Hex(), t)
+		expectString(result.Wallet.Family, "Test", t)
+	})
+
+	t.Run("EIP-1967 proxies should report their implementation and admin", func(t *testing.T) {
+		registry := WalletRegistry{Implementations: map[common.Address]WalletVersion{implementation: {"Test", "2.0.0"}}}
+		result := verify(walletCode, map[common.Hash]common.Hash{
+			EIP1967ImplementationSlot: common.BytesToHash(implementation.Bytes()),
+			EIP1967AdminSlot:          common.BytesToHash(admin.Bytes()),
+		}, registry)
+
+		expectString(string(result.Wallet.ProxyType), string(ProxyTypeEIP1967), t)
+		expectString(result.Wallet.Admin.Hex(), admin.Hex(), t)
+		expectString(result.Wallet.Version, "2.0.0", t)
+	})
+
+	t.Run("Safe proxies of known singletons should be identified", func(t *testing.T) {
+		result := verify(walletCode, map[common.Hash]common.Hash{{}: common.BytesToHash(safeSingleton.Bytes())}, KnownWallets)
+
+		expectBool(result.Authorized, false, t)
+		expectString(string(result.Wallet.ProxyType), string(ProxyTypeSafe), t)
+		expectString(result.Wallet.Family, "Safe", t)
+		expectString(result.Wallet.Version, "1.3.0", t)
+	})
+
+	t.Run("Known wallet implementations should be identified behind their proxies", func(t *testing.T) {
+		result := verify(common.FromHex(eip1967ProxyCode), map[common.Hash]common.Hash{
+			{}:                        scMessageHash("foo"),
+			EIP1967ImplementationSlot: common.BytesToHash(coinbaseSmartWallet.Bytes()),
+		}, KnownWallets)
+
+		expectBool(result.Authorized, true, t)
+		expectString(string(result.Wallet.ProxyType), string(ProxyTypeEIP1967), t)
+		expectString(result.Wallet.Implementation.Hex(), coinbaseSmartWallet.Hex(), t)
+		expectString(result.Wallet.Family, "Coinbase Smart Wallet", t)
+		expectString(result.Wallet.Version, "1", t)
+
+		cloneCode := append(append(append([]byte{}, _EIP1167Prefix...), lightAccount.Bytes()...), _EIP1167Suffix...)
+		result = verify(cloneCode, map[common.Hash]common.Hash{{}: scMessageHash("foo")}, KnownWallets)
+
+		expectBool(result.Authorized, true, t)
+		expectString(string(result.Wallet.ProxyType), string(ProxyTypeEIP1167), t)
+		expectString(result.Wallet.Family, "LightAccount", t)
+		expectString(result.Wallet.Version, "2.0.0", t)
+	})
+
+	t.Run("Safe singletons should be identified by their code hash", func(t *testing.T) {
+		// the singleton only validates signatures through its fallback handler, so it's detected without a Verify
+		simulator := NewSimulator(nil, &mockStateReader{
+			header: &types.Header{Number: big.NewInt(12244000), GasLimit: 15000000, Difficulty: new(big.Int)},
+			code:   map[common.Address][]byte{wallet: loadBytecodeFixture("safe_v1.3.0_singleton", t)},
+		}, SimulatorConfig{})
+		authenticator := NewAuthenticator(nil, simulator, WithWalletDetection(KnownWallets))
+
+		info, err := authenticator.detectWallet(wallet)
+		checkError(err, t)
+		expectString(string(info.ProxyType), "", t)
+		expectString(info.Family, "Safe", t)
+		expectString(info.Version, "1.3.0", t)
+	})
+
+	t.Run("Unknown wallets should only report their code hash", func(t *testing.T) {
+		result := verify(walletCode, map[common.Hash]common.Hash{{}: common.BytesToHash(admin.Bytes())}, KnownWallets)
+
+		expectString(result.Wallet.CodeHash.Hex(), ethCrypto.Keccak256Hash(walletCode).Hex(), t)
+		expectString(string(result.Wallet.ProxyType), "", t)
+		expectString(result.Wallet.Family, "", t)
+	})
+}